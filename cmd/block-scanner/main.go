@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"maps"
 	"os"
 	"os/signal"
 	"syscall"
@@ -26,43 +29,55 @@ func main() {
 		logger.Fatalf("Failed to create logger: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		runBackfill(ctx, cancel, cfg, logger, os.Args[2:])
+		return
+	}
+
+	runWatcher(ctx, cfg, logger)
+}
+
+// runWatcher follows the head of every configured chain until a shutdown signal is received
+func runWatcher(ctx context.Context, cfg *config.Config, logger logger.Logger) {
 	// Initialize HTTP server
 	httpServer := server.NewServer(ctx, logger, cfg.Port)
 	go httpServer.Start(ctx)
 
-	// Load addresses
-	addressMap, err := storage.ReadAddresses(cfg.AddressesFilePath)
-	if err != nil {
-		logger.Fatalf("Failed to load addresses: %v", err)
-	}
-
-	// Build bloom filter
-	bloomFilter := bloom.New(cfg.BloomFilterSize, cfg.BloomFilterHash)
-	for addr := range addressMap {
-		bloomFilter.Add(addr)
-	}
-
-	// Kafka setup
+	// Kafka setup, shared by all chains as every event carries its chain id
 	brokers := cfg.KafkaBrokers
 	topic := cfg.KafkaTopic
 	producer := events.NewProducer(ctx, logger, brokers, topic)
 	defer producer.Close()
 
-	// Init scanner
-	watcher, err := scanner.New(ctx, cfg, logger, bloomFilter, addressMap, producer)
-	if err != nil {
-		logger.Fatalf("Failed to init scanner: %v", err)
-	}
+	var pendingProducer *events.KafkaProducer
+	for _, chainCfg := range cfg.ChainConfigs() {
+		chainLogger := logger.With("chain", chainCfg.Chain)
+		addressMap, bloomFilter := loadAddresses(chainCfg, chainLogger)
 
-	if err := watcher.Start(); err != nil {
-		logger.Fatalf("Failed to start scanner: %v", err)
-	}
-	defer watcher.Stop()
+		// Init scanner
+		watcher, err := scanner.New(ctx, chainCfg, chainLogger, bloomFilter, addressMap, producer)
+		if err != nil {
+			chainLogger.Fatalf("Failed to init scanner: %v", err)
+		}
 
-	logger.Infow("Scanner started",
-		"node", cfg.EthereumNodeURL,
-		"bloom_size", cfg.BloomFilterSize,
-	)
+		if chainCfg.MempoolEnabled {
+			if pendingProducer == nil {
+				pendingProducer = events.NewProducer(ctx, logger, brokers, cfg.KafkaPendingTopic)
+				defer pendingProducer.Close()
+			}
+			watcher.WatchMempool(pendingProducer, chainCfg.PendingTxTTL)
+		}
+
+		if err := watcher.Start(); err != nil {
+			chainLogger.Fatalf("Failed to start scanner: %v", err)
+		}
+		defer watcher.Stop()
+
+		chainLogger.Infow("Scanner started",
+			"nodes", len(chainCfg.EthereumNodeURLs),
+			"bloom_size", chainCfg.BloomFilterSize,
+		)
+	}
 
 	// Wait for shutdown signal
 	sigChan := make(chan os.Signal, 1)
@@ -71,3 +86,92 @@ func main() {
 
 	logger.Infof("Shutting down...")
 }
+
+// runBackfill rescans a fixed block range and exits when it is done
+func runBackfill(ctx context.Context, cancel context.CancelFunc, cfg *config.Config, logger logger.Logger, args []string) {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	from := flags.Uint64("from", 0, "first block to scan")
+	to := flags.Uint64("to", 0, "last block to scan (inclusive)")
+	chainName := flags.String("chain", "", "chain to backfill, required when several CHAINS are configured")
+	checkpoint := flags.String("checkpoint", "", "checkpoint file used to resume the backfill (default backfill-<from>-<to>.txt)")
+	if err := flags.Parse(args); err != nil {
+		logger.Fatalf("Failed to parse backfill flags: %v", err)
+	}
+	if *to == 0 || *from > *to {
+		logger.Fatalf("Invalid backfill range: --from %d --to %d", *from, *to)
+	}
+	cfg = selectChain(cfg, *chainName, logger)
+	logger = logger.With("chain", cfg.Chain)
+	if *checkpoint == "" {
+		*checkpoint = fmt.Sprintf("backfill-%d-%d.txt", *from, *to)
+		if *chainName != "" {
+			*checkpoint = fmt.Sprintf("backfill-%s-%d-%d.txt", *chainName, *from, *to)
+		}
+	}
+
+	addressMap, bloomFilter := loadAddresses(cfg, logger)
+
+	producer := events.NewProducer(ctx, logger, cfg.KafkaBrokers, cfg.KafkaTopic)
+	defer producer.Close()
+
+	backfiller, err := scanner.New(ctx, cfg, logger, bloomFilter, addressMap, producer)
+	if err != nil {
+		logger.Fatalf("Failed to init scanner: %v", err)
+	}
+
+	// Stop after the current block on shutdown, the checkpoint lets us resume
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		logger.Infof("Shutting down backfill...")
+		cancel()
+	}()
+
+	if err := backfiller.Backfill(*from, *to, *checkpoint); err != nil {
+		logger.Errorf("Backfill stopped: %v", err)
+	}
+}
+
+// selectChain returns the configuration of the named chain. The name may be
+// omitted when only one chain is configured.
+func selectChain(cfg *config.Config, name string, logger logger.Logger) *config.Config {
+	chains := cfg.ChainConfigs()
+	if name == "" {
+		if len(chains) > 1 {
+			logger.Fatalf("Several chains are configured, select one with --chain")
+		}
+		return chains[0]
+	}
+	for _, chainCfg := range chains {
+		if chainCfg.Chain == name {
+			return chainCfg
+		}
+	}
+	logger.Fatalf("Unknown chain: %s", name)
+	return nil
+}
+
+// loadAddresses reads the monitored addresses and builds the bloom filter for them
+func loadAddresses(cfg *config.Config, logger logger.Logger) (map[string]string, *bloom.AddressBloomFilter) {
+	addressMap, err := storage.ReadAddresses(cfg.AddressesFilePath)
+	if err != nil {
+		logger.Fatalf("Failed to load addresses: %v", err)
+	}
+
+	// Watched contracts are kept apart from the address list, which is never rewritten
+	if cfg.WatchedAddressesFile != "" {
+		watched, err := storage.ReadAddresses(cfg.WatchedAddressesFile)
+		if err != nil && !os.IsNotExist(err) {
+			logger.Fatalf("Failed to load watched addresses: %v", err)
+		}
+		maps.Copy(addressMap, watched)
+	}
+
+	bloomFilter := bloom.New(cfg.BloomFilterSize, cfg.BloomFilterHash)
+	for addr := range addressMap {
+		bloomFilter.Add(addr)
+	}
+
+	return addressMap, bloomFilter
+}
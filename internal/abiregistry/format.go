@@ -0,0 +1,70 @@
+package abiregistry
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// formatValue converts a decoded ABI value to a JSON friendly form: integers
+// become decimal strings so no precision is lost, addresses lowercase hex,
+// bytes hex strings, arrays lists and tuples objects keyed by component name
+func formatValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case common.Address:
+		return strings.ToLower(v.Hex())
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case string, bool:
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Array:
+		// Fixed size bytes such as bytes32
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		return formatList(rv)
+	case reflect.Slice:
+		return formatList(rv)
+	case reflect.Struct:
+		fields := make(map[string]interface{}, rv.NumField())
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			name := field.Name
+			if tag, ok := field.Tag.Lookup("json"); ok {
+				name = tag
+			}
+			fields[name] = formatValue(rv.Field(i).Interface())
+		}
+		return fields
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return formatValue(rv.Elem().Interface())
+	}
+	return value
+}
+
+func formatList(rv reflect.Value) []interface{} {
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = formatValue(rv.Index(i).Interface())
+	}
+	return list
+}
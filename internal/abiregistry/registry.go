@@ -0,0 +1,258 @@
+// Package abiregistry decodes transaction input with known contract ABIs and
+// a table of 4-byte function selectors
+package abiregistry
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Method is a function that can be recognized by its selector. Inputs is nil
+// when the argument types of a selector table entry cannot be decoded.
+type Method struct {
+	Name      string
+	Signature string
+	Inputs    abi.Arguments
+}
+
+// Call is the decoded input of a transaction
+type Call struct {
+	Selector  string
+	Method    *Method
+	Arguments []Argument
+}
+
+// Argument is a decoded argument of a call
+type Argument struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Registry maps function selectors to methods. Methods of ABIs named after a
+// contract address only apply to that contract and take precedence.
+type Registry struct {
+	methods   map[[4]byte]*Method
+	contracts map[common.Address]map[[4]byte]*Method
+}
+
+// New returns an empty registry
+func New() *Registry {
+	return &Registry{
+		methods:   make(map[[4]byte]*Method),
+		contracts: make(map[common.Address]map[[4]byte]*Method),
+	}
+}
+
+// Load returns a registry with the ABIs of dir and the selectors of
+// selectorsFile. Empty paths are skipped.
+func Load(dir, selectorsFile string) (*Registry, error) {
+	r := New()
+	if dir != "" {
+		if err := r.LoadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	if selectorsFile != "" {
+		if err := r.LoadSelectors(selectorsFile); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// LoadDir loads every .json ABI of a directory. Files may hold a plain ABI or
+// a compiler artifact with an "abi" field. A file named <address>.json only
+// applies to the contract at that address; the methods of other files apply
+// to any contract, the first file in name order winning selector clashes.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		parsed, err := readABI(file)
+		if err != nil {
+			return fmt.Errorf("failed to read ABI %s: %v", file, err)
+		}
+
+		methods := r.methods
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		if common.IsHexAddress(name) {
+			address := common.HexToAddress(name)
+			if r.contracts[address] == nil {
+				r.contracts[address] = make(map[[4]byte]*Method)
+			}
+			methods = r.contracts[address]
+		}
+
+		for _, method := range parsed.Methods {
+			var selector [4]byte
+			copy(selector[:], method.ID)
+			if _, ok := methods[selector]; ok {
+				continue
+			}
+			methods[selector] = &Method{Name: method.RawName, Signature: method.Sig, Inputs: method.Inputs}
+		}
+	}
+	return nil
+}
+
+// readABI parses a JSON ABI or the "abi" field of a compiler artifact
+func readABI(file string) (abi.ABI, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if json.Unmarshal(data, &artifact) == nil && len(artifact.ABI) > 0 {
+		data = artifact.ABI
+	}
+	return abi.JSON(bytes.NewReader(data))
+}
+
+// LoadSelectors loads a CSV table of selector,signature rows such as
+// 0xa9059cbb,transfer(address,uint256). Selectors already known from an ABI
+// are kept, as ABIs also name the arguments.
+func (r *Registry) LoadSelectors(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		rawSelector := strings.TrimSpace(record[0])
+		signature := strings.TrimSpace(strings.Join(record[1:], ","))
+		if i == 0 && strings.EqualFold(rawSelector, "selector") {
+			continue
+		}
+
+		method, err := parseSignature(signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature %q in %s: %v", signature, filename, err)
+		}
+		selector, err := hexutil.Decode(rawSelector)
+		if err != nil || len(selector) != 4 || !bytes.Equal(selector, crypto.Keccak256([]byte(method.Signature))[:4]) {
+			return fmt.Errorf("selector %s does not match %s in %s", rawSelector, signature, filename)
+		}
+
+		var key [4]byte
+		copy(key[:], selector)
+		if _, ok := r.methods[key]; !ok {
+			r.methods[key] = method
+		}
+	}
+	return nil
+}
+
+// parseSignature parses a function signature such as transfer(address,uint256).
+// Arguments are named by position. Tuple arguments cannot be built without
+// their component names, so such methods are recognized but not decoded.
+func parseSignature(signature string) (*Method, error) {
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return nil, fmt.Errorf("expected name(types)")
+	}
+	method := &Method{Name: signature[:open], Signature: signature}
+
+	types := splitTypes(signature[open+1 : len(signature)-1])
+	inputs := make(abi.Arguments, 0, len(types))
+	for i, t := range types {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			return method, nil
+		}
+		inputs = append(inputs, abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: typ})
+	}
+	method.Inputs = inputs
+	return method, nil
+}
+
+// splitTypes splits a comma separated type list, keeping tuples together
+func splitTypes(list string) []string {
+	if list == "" {
+		return nil
+	}
+
+	var types []string
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				types = append(types, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(types, list[start:])
+}
+
+// Decode decodes the input of a call to contract. It returns nil for inputs
+// shorter than a selector, and a call without method for unknown selectors.
+// Arguments are left out when they do not match the method's inputs.
+func (r *Registry) Decode(contract common.Address, data []byte) *Call {
+	if len(data) < 4 {
+		return nil
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+	call := &Call{Selector: hexutil.Encode(selector[:])}
+
+	method, ok := r.contracts[contract][selector]
+	if !ok {
+		method, ok = r.methods[selector]
+	}
+	if !ok {
+		return call
+	}
+	call.Method = method
+
+	if method.Inputs == nil {
+		return call
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return call
+	}
+	call.Arguments = make([]Argument, len(values))
+	for i, value := range values {
+		call.Arguments[i] = Argument{
+			Name:  method.Inputs[i].Name,
+			Type:  method.Inputs[i].Type.String(),
+			Value: formatValue(value),
+		}
+	}
+	return call
+}
@@ -0,0 +1,108 @@
+package abiregistry_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/abiregistry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const erc20ABI = `[
+	{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}], "outputs": [{"type": "bool"}]},
+	{"type": "function", "name": "approve", "inputs": [{"name": "spender", "type": "address"}, {"name": "amount", "type": "uint256"}], "outputs": [{"type": "bool"}]}
+]`
+
+// The router's transfer shares the ERC-20 selector with its own argument names
+const routerArtifact = `{"contractName": "Router", "abi": [
+	{"type": "function", "name": "transfer", "inputs": [{"name": "recipient", "type": "address"}, {"name": "value", "type": "uint256"}], "outputs": []}
+]}`
+
+var (
+	router    = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	recipient = common.HexToAddress("0x742d35cc6634c0532925a3b844bc454e4438f44e")
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// transferInput returns the input of transfer(recipient, amount)
+func transferInput(amount int64) []byte {
+	input := hexutil.MustDecode("0xa9059cbb")
+	input = append(input, common.LeftPadBytes(recipient.Bytes(), 32)...)
+	return append(input, common.BigToHash(big.NewInt(amount)).Bytes()...)
+}
+
+func TestRegistry_DecodesWithABIs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "erc20.json", erc20ABI)
+	writeFile(t, dir, router.Hex()+".json", routerArtifact)
+
+	registry, err := abiregistry.Load(dir, "")
+	require.NoError(t, err)
+
+	call := registry.Decode(common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"), transferInput(5))
+	require.NotNil(t, call.Method)
+	assert.Equal(t, "0xa9059cbb", call.Selector)
+	assert.Equal(t, "transfer", call.Method.Name)
+	assert.Equal(t, "transfer(address,uint256)", call.Method.Signature)
+	assert.Equal(t, []abiregistry.Argument{
+		{Name: "to", Type: "address", Value: "0x742d35cc6634c0532925a3b844bc454e4438f44e"},
+		{Name: "amount", Type: "uint256", Value: "5"},
+	}, call.Arguments)
+
+	// The ABI named after a contract applies to that contract
+	call = registry.Decode(router, transferInput(5))
+	require.NotNil(t, call.Method)
+	assert.Equal(t, "recipient", call.Arguments[0].Name)
+
+	// Unknown selectors and malformed arguments
+	call = registry.Decode(router, hexutil.MustDecode("0xdeadbeef"))
+	assert.Equal(t, "0xdeadbeef", call.Selector)
+	assert.Nil(t, call.Method)
+
+	call = registry.Decode(router, transferInput(5)[:20])
+	require.NotNil(t, call.Method)
+	assert.Empty(t, call.Arguments)
+
+	assert.Nil(t, registry.Decode(router, nil))
+}
+
+func TestRegistry_DecodesWithSelectorTable(t *testing.T) {
+	dir := t.TempDir()
+	table := writeFile(t, dir, "selectors.csv", `selector,signature
+0xa9059cbb,transfer(address,uint256)
+0x2e1a7d4d,withdraw(uint256)
+0x38ed1739,"swapExactTokensForTokens(uint256,uint256,address[],address,uint256)"
+0x1fad948c,"handleOps((address,uint256,bytes,bytes,uint256,uint256,uint256,uint256,uint256,bytes,bytes)[],address)"
+`)
+
+	registry, err := abiregistry.Load("", table)
+	require.NoError(t, err)
+
+	call := registry.Decode(router, transferInput(7))
+	require.NotNil(t, call.Method)
+	assert.Equal(t, "transfer", call.Method.Name)
+	assert.Equal(t, []abiregistry.Argument{
+		{Name: "arg0", Type: "address", Value: "0x742d35cc6634c0532925a3b844bc454e4438f44e"},
+		{Name: "arg1", Type: "uint256", Value: "7"},
+	}, call.Arguments)
+
+	// Tuple arguments are recognized but not decoded
+	call = registry.Decode(router, hexutil.MustDecode("0x1fad948c"))
+	require.NotNil(t, call.Method)
+	assert.Equal(t, "handleOps", call.Method.Name)
+	assert.Empty(t, call.Arguments)
+
+	bad := writeFile(t, dir, "bad.csv", "0xdeadbeef,transfer(address,uint256)\n")
+	_, err = abiregistry.Load("", bad)
+	assert.Error(t, err)
+}
@@ -1,9 +1,15 @@
 package bloom
 
-import bloom "github.com/bits-and-blooms/bloom/v3"
+import (
+	"sync"
 
-// AddressBloomFilter wraps a bloom.BloomFilter for Ethereum addresses
+	bloom "github.com/bits-and-blooms/bloom/v3"
+)
+
+// AddressBloomFilter wraps a bloom.BloomFilter for Ethereum addresses.
+// It is safe for concurrent use.
 type AddressBloomFilter struct {
+	mu     sync.RWMutex
 	filter *bloom.BloomFilter
 }
 
@@ -16,11 +22,17 @@ func New(size uint, hash uint) *AddressBloomFilter {
 
 // Add inserts a single address into the bloom filter
 func (b *AddressBloomFilter) Add(address string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	b.filter.Add([]byte(address))
 }
 
 // Test checks if a given address might be in the filter
 func (b *AddressBloomFilter) Test(address string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
 	return b.filter.Test([]byte(address))
 }
 
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// ErrPendingUnsupported is returned when the client cannot subscribe to pending transactions
+var ErrPendingUnsupported = errors.New("pending transaction subscriptions are not supported by the client")
+
+// PendingSubscriber is implemented by clients that deliver pending transactions directly
+type PendingSubscriber interface {
+	SubscribePendingTransactions(ctx context.Context, ch chan<- *types.Transaction) (ethereum.Subscription, error)
+}
+
+// SubscribePendingTransactions subscribes to newPendingTransactions. Full
+// transaction bodies are requested first; nodes that only announce hashes are
+// served by fetching every announced transaction.
+func SubscribePendingTransactions(ctx context.Context, client ChainClient, ch chan<- *types.Transaction) (ethereum.Subscription, error) {
+	switch c := client.(type) {
+	case PendingSubscriber:
+		return c.SubscribePendingTransactions(ctx, ch)
+	case rpcClient:
+		rc := c.Client()
+		if sub, err := rc.EthSubscribe(ctx, ch, "newPendingTransactions", true); err == nil {
+			return sub, nil
+		}
+
+		hashes := make(chan common.Hash, 256)
+		hashSub, err := rc.EthSubscribe(ctx, hashes, "newPendingTransactions")
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to pending transactions: %v", err)
+		}
+		return fetchPendingTransactions(ctx, ethclient.NewClient(rc), hashSub, hashes, ch), nil
+	default:
+		return nil, ErrPendingUnsupported
+	}
+}
+
+// fetchPendingTransactions resolves announced pending transaction hashes into
+// transactions. Transactions that are gone by the time they are fetched are skipped.
+func fetchPendingTransactions(ctx context.Context, client *ethclient.Client, hashSub ethereum.Subscription, hashes <-chan common.Hash, ch chan<- *types.Transaction) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer hashSub.Unsubscribe()
+
+		for {
+			select {
+			case hash := <-hashes:
+				tx, _, err := client.TransactionByHash(ctx, hash)
+				if err != nil {
+					continue
+				}
+				select {
+				case ch <- tx:
+				case <-quit:
+					return nil
+				}
+			case err := <-hashSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
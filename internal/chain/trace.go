@@ -0,0 +1,99 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// JSON-RPC error codes nodes and providers answer with when the debug namespace
+// is missing or disabled
+const (
+	invalidRequestCode = -32600
+	methodNotFoundCode = -32601
+	serverErrorCode    = -32000
+)
+
+// ErrTracingUnsupported is returned when the node does not serve the debug namespace
+var ErrTracingUnsupported = errors.New("debug tracing is not supported by the node")
+
+// CallFrame is a call frame produced by the callTracer
+type CallFrame struct {
+	Type  string          `json:"type"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Calls []CallFrame     `json:"calls,omitempty"`
+}
+
+// TxTrace is the call trace of a single transaction of a block
+type TxTrace struct {
+	TxHash common.Hash `json:"txHash"`
+	Result CallFrame   `json:"result"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// BlockTracer is implemented by clients that can trace all transactions of a block
+type BlockTracer interface {
+	TraceBlockByHash(ctx context.Context, hash common.Hash) ([]TxTrace, error)
+}
+
+// rpcClient is implemented by clients exposing their raw JSON-RPC connection,
+// such as *ethclient.Client
+type rpcClient interface {
+	Client() *rpc.Client
+}
+
+// TraceBlock traces a block with the callTracer using debug_traceBlockByHash,
+// so the traces always belong to the block that was fetched even across a reorg.
+// It returns ErrTracingUnsupported when the client or node cannot trace.
+func TraceBlock(ctx context.Context, client ChainClient, hash common.Hash) ([]TxTrace, error) {
+	switch c := client.(type) {
+	case BlockTracer:
+		return c.TraceBlockByHash(ctx, hash)
+	case rpcClient:
+		var traces []TxTrace
+		err := c.Client().CallContext(ctx, &traces, "debug_traceBlockByHash",
+			hash, map[string]string{"tracer": "callTracer"})
+		if tracingUnsupported(err) {
+			return nil, ErrTracingUnsupported
+		}
+		if err != nil {
+			return nil, fmt.Errorf("debug_traceBlockByHash failed: %v", err)
+		}
+		return traces, nil
+	default:
+		return nil, ErrTracingUnsupported
+	}
+}
+
+// tracingUnsupported reports whether err means the node or its provider does
+// not serve debug tracing at all, rather than that this block failed to trace.
+// Providers reject the namespace with a HTTP status or with their own messages.
+func tracingUnsupported(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusForbidden || httpErr.StatusCode == http.StatusMethodNotAllowed
+	}
+
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.ErrorCode() {
+	case methodNotFoundCode:
+		return true
+	case invalidRequestCode, serverErrorCode:
+		message := strings.ToLower(rpcErr.Error())
+		return strings.Contains(message, "not available") || strings.Contains(message, "not whitelisted")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,92 @@
+package chain_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcServer answers every JSON-RPC request with the given HTTP status and,
+// for 200, with the given result or error object
+func rpcServer(t *testing.T, status int, result, rpcError any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "debug_traceBlockByHash", request.Method)
+
+		if status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		response := map[string]any{"jsonrpc": "2.0", "id": request.ID}
+		if rpcError != nil {
+			response["error"] = rpcError
+		} else {
+			response["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChain_TraceBlock(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	traces := []map[string]any{{
+		"txHash": txHash,
+		"result": map[string]any{"type": "CALL", "from": common.HexToAddress("0x02"), "value": "0x1"},
+	}}
+
+	tests := []struct {
+		name        string
+		status      int
+		rpcError    any
+		unsupported bool
+		wantError   bool
+	}{
+		{name: "traces block", status: http.StatusOK},
+		{name: "forbidden", status: http.StatusForbidden, unsupported: true},
+		{name: "method not allowed", status: http.StatusMethodNotAllowed, unsupported: true},
+		{name: "method not found", status: http.StatusOK, rpcError: map[string]any{"code": -32601, "message": "the method debug_traceBlockByHash does not exist/is not available"}, unsupported: true},
+		{name: "namespace not available", status: http.StatusOK, rpcError: map[string]any{"code": -32000, "message": "debug namespace is not available on this plan"}, unsupported: true},
+		{name: "method not whitelisted", status: http.StatusOK, rpcError: map[string]any{"code": -32600, "message": "Method not whitelisted"}, unsupported: true},
+		{name: "tracing failure", status: http.StatusOK, rpcError: map[string]any{"code": -32000, "message": "execution timeout"}, wantError: true},
+		{name: "server error", status: http.StatusInternalServerError, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rpcServer(t, tt.status, traces, tt.rpcError)
+			client, err := chain.Dial(context.Background(), server.URL)
+			require.NoError(t, err)
+
+			got, err := chain.TraceBlock(context.Background(), client, common.HexToHash("0xb1"))
+			switch {
+			case tt.unsupported:
+				assert.ErrorIs(t, err, chain.ErrTracingUnsupported)
+			case tt.wantError:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, chain.ErrTracingUnsupported)
+			default:
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				assert.Equal(t, txHash, got[0].TxHash)
+				assert.Equal(t, "CALL", got[0].Result.Type)
+			}
+		})
+	}
+}
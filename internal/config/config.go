@@ -5,20 +5,56 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	EthereumNodeURL   string
-	AddressesFilePath string
-	BloomFilterSize   uint
-	BloomFilterHash   uint
-	CheckpointFile    string
-	LogLevel          string
-	KafkaBrokers      []string
-	KafkaTopic        string
-	Port              string
+	Chain                string
+	ChainID              uint
+	Chains               []string
+	EthereumNodeURL      string
+	EthereumNodeURLs     []string
+	AddressesFilePath    string
+	WatchedAddressesFile string
+	BloomFilterSize      uint
+	BloomFilterHash      uint
+	CheckpointFile       string
+	TokenMetadataFile    string
+	LogLevel             string
+	KafkaBrokers         []string
+	KafkaTopic           string
+	KafkaPendingTopic    string
+	Port                 string
+	FetchConcurrency     uint
+	HeadSource           string
+	PollInterval         time.Duration
+
+	ProviderMaxLag         uint
+	ProviderHealthInterval time.Duration
+
+	QuorumNodeURLs []string
+	QuorumSize     uint
+
+	FinalityPolicy  string
+	LifecycleEvents bool
+	Confirmations   uint
+
+	SkipFailedTxs          bool
+	TraceInternalTransfers bool
+	WatchDeployedContracts bool
+	BlobInboxes            []string
+
+	PriceSource    string
+	PriceFile      string
+	ChainlinkFeeds []string
+
+	ABIDir        string
+	SelectorsFile string
+
+	MempoolEnabled bool
+	PendingTxTTL   time.Duration
 }
 
 func Load() *Config {
@@ -26,18 +62,123 @@ func Load() *Config {
 		log.Println("No .env file found, using environment/default values")
 	}
 
+	nodeURL := getEnv("ETH_NODE_URL", "wss://ethereum-rpc.com")
+
 	return &Config{
-		EthereumNodeURL:   getEnv("ETH_NODE_URL", "wss://ethereum-rpc.com"),
-		AddressesFilePath: getEnv("ADDRESSES_FILE", "addresses.csv"),
-		BloomFilterSize:   getEnvAsUint("BLOOM_FILTER_SIZE", 10000000),
-		BloomFilterHash:   getEnvAsUint("BLOOM_FILTER_HASH", 7),
-		CheckpointFile:    getEnv("CHECKPOINT_FILE", "checkpoint.txt"),
-		KafkaBrokers:      getEnvAsSlice("KAFKA_BROKERS", []string{"localhost:9093"}, ","),
-		KafkaTopic:        getEnv("KAFKA_TOPIC", "ethereum-tx-events"),
-		Port:              getEnv("PORT", "8080"),
+		Chain:                getEnv("CHAIN_NAME", "ethereum"),
+		ChainID:              getEnvAsUint("CHAIN_ID", 0),
+		Chains:               getEnvAsSlice("CHAINS", nil, ","),
+		EthereumNodeURL:      nodeURL,
+		EthereumNodeURLs:     getEnvAsSlice("ETH_NODE_URLS", []string{nodeURL}, ","),
+		AddressesFilePath:    getEnv("ADDRESSES_FILE", "addresses.csv"),
+		WatchedAddressesFile: getEnv("WATCHED_ADDRESSES_FILE", "watched-addresses.csv"),
+		BloomFilterSize:      getEnvAsUint("BLOOM_FILTER_SIZE", 10000000),
+		BloomFilterHash:      getEnvAsUint("BLOOM_FILTER_HASH", 7),
+		CheckpointFile:       getEnv("CHECKPOINT_FILE", "checkpoint.txt"),
+		TokenMetadataFile:    getEnv("TOKEN_METADATA_FILE", "token-metadata.json"),
+		KafkaBrokers:         getEnvAsSlice("KAFKA_BROKERS", []string{"localhost:9093"}, ","),
+		KafkaTopic:           getEnv("KAFKA_TOPIC", "ethereum-tx-events"),
+		KafkaPendingTopic:    getEnv("KAFKA_PENDING_TOPIC", "ethereum-pending-tx-events"),
+		Port:                 getEnv("PORT", "8080"),
+		FetchConcurrency:     getEnvAsUint("FETCH_CONCURRENCY", 4),
+		HeadSource:           getEnv("HEAD_SOURCE", "auto"),
+		PollInterval:         getEnvAsDuration("POLL_INTERVAL", 12*time.Second),
+
+		ProviderMaxLag:         getEnvAsUint("PROVIDER_MAX_LAG", 5),
+		ProviderHealthInterval: getEnvAsDuration("PROVIDER_HEALTH_INTERVAL", 15*time.Second),
+
+		QuorumNodeURLs: getEnvAsSlice("QUORUM_NODE_URLS", nil, ","),
+		QuorumSize:     getEnvAsUint("QUORUM_SIZE", 2),
+
+		FinalityPolicy:  getEnv("FINALITY_POLICY", "depth"),
+		Confirmations:   getEnvAsUint("CONFIRMATIONS", 12),
+		LifecycleEvents: getEnvAsBool("LIFECYCLE_EVENTS", false),
+
+		SkipFailedTxs:          getEnvAsBool("SKIP_FAILED_TXS", false),
+		TraceInternalTransfers: getEnvAsBool("TRACE_INTERNAL_TRANSFERS", false),
+		WatchDeployedContracts: getEnvAsBool("WATCH_DEPLOYED_CONTRACTS", false),
+		BlobInboxes:            getEnvAsSlice("BLOB_INBOXES", nil, ","),
+
+		PriceSource:    getEnv("PRICE_SOURCE", "none"),
+		PriceFile:      getEnv("PRICE_FILE", "prices.json"),
+		ChainlinkFeeds: getEnvAsSlice("CHAINLINK_FEEDS", nil, ","),
+
+		ABIDir:        getEnv("ABI_DIR", ""),
+		SelectorsFile: getEnv("SELECTORS_FILE", ""),
+
+		MempoolEnabled: getEnvAsBool("MEMPOOL_ENABLED", false),
+		PendingTxTTL:   getEnvAsDuration("PENDING_TX_TTL", 30*time.Minute),
 	}
 }
 
+// ChainConfigs returns the configuration of every chain scanned by the process.
+// Without CHAINS this is the configuration itself. Otherwise every chain named
+// in CHAINS reads its settings from variables prefixed with its upper-cased
+// name, e.g. BASE_ETH_NODE_URLS or ARBITRUM_ONE_CONFIRMATIONS for arbitrum-one,
+// and falls back to the shared value. Node URLs, blob inboxes and Chainlink
+// feeds have no fallback as they only apply to one chain.
+func (c *Config) ChainConfigs() []*Config {
+	if len(c.Chains) == 0 {
+		return []*Config{c}
+	}
+
+	configs := make([]*Config, 0, len(c.Chains))
+	for _, name := range c.Chains {
+		configs = append(configs, c.forChain(name))
+	}
+	return configs
+}
+
+// forChain returns a copy of the configuration with the overrides of a chain applied
+func (c *Config) forChain(name string) *Config {
+	prefix := strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	nodeURL := getEnv(prefix+"ETH_NODE_URL", "")
+	var nodeURLs []string
+	if nodeURL != "" {
+		nodeURLs = []string{nodeURL}
+	}
+
+	chain := *c
+	chain.Chain = name
+	chain.ChainID = getEnvAsUint(prefix+"CHAIN_ID", 0)
+	chain.Chains = nil
+	chain.EthereumNodeURL = nodeURL
+	chain.EthereumNodeURLs = getEnvAsSlice(prefix+"ETH_NODE_URLS", nodeURLs, ",")
+	chain.AddressesFilePath = getEnv(prefix+"ADDRESSES_FILE", c.AddressesFilePath)
+	chain.WatchedAddressesFile = getEnv(prefix+"WATCHED_ADDRESSES_FILE", "watched-addresses-"+name+".csv")
+	chain.CheckpointFile = getEnv(prefix+"CHECKPOINT_FILE", "checkpoint-"+name+".txt")
+	chain.TokenMetadataFile = getEnv(prefix+"TOKEN_METADATA_FILE", "token-metadata-"+name+".json")
+	chain.FetchConcurrency = getEnvAsUint(prefix+"FETCH_CONCURRENCY", c.FetchConcurrency)
+	chain.HeadSource = getEnv(prefix+"HEAD_SOURCE", c.HeadSource)
+	chain.PollInterval = getEnvAsDuration(prefix+"POLL_INTERVAL", c.PollInterval)
+
+	chain.ProviderMaxLag = getEnvAsUint(prefix+"PROVIDER_MAX_LAG", c.ProviderMaxLag)
+	chain.ProviderHealthInterval = getEnvAsDuration(prefix+"PROVIDER_HEALTH_INTERVAL", c.ProviderHealthInterval)
+
+	chain.QuorumNodeURLs = getEnvAsSlice(prefix+"QUORUM_NODE_URLS", nil, ",")
+	chain.QuorumSize = getEnvAsUint(prefix+"QUORUM_SIZE", c.QuorumSize)
+
+	chain.FinalityPolicy = getEnv(prefix+"FINALITY_POLICY", c.FinalityPolicy)
+	chain.Confirmations = getEnvAsUint(prefix+"CONFIRMATIONS", c.Confirmations)
+	chain.LifecycleEvents = getEnvAsBool(prefix+"LIFECYCLE_EVENTS", c.LifecycleEvents)
+
+	chain.SkipFailedTxs = getEnvAsBool(prefix+"SKIP_FAILED_TXS", c.SkipFailedTxs)
+	chain.TraceInternalTransfers = getEnvAsBool(prefix+"TRACE_INTERNAL_TRANSFERS", c.TraceInternalTransfers)
+	chain.WatchDeployedContracts = getEnvAsBool(prefix+"WATCH_DEPLOYED_CONTRACTS", c.WatchDeployedContracts)
+	chain.BlobInboxes = getEnvAsSlice(prefix+"BLOB_INBOXES", nil, ",")
+
+	chain.PriceSource = getEnv(prefix+"PRICE_SOURCE", c.PriceSource)
+	chain.PriceFile = getEnv(prefix+"PRICE_FILE", c.PriceFile)
+	chain.ChainlinkFeeds = getEnvAsSlice(prefix+"CHAINLINK_FEEDS", nil, ",")
+
+	chain.ABIDir = getEnv(prefix+"ABI_DIR", c.ABIDir)
+	chain.SelectorsFile = getEnv(prefix+"SELECTORS_FILE", c.SelectorsFile)
+
+	chain.MempoolEnabled = getEnvAsBool(prefix+"MEMPOOL_ENABLED", c.MempoolEnabled)
+	chain.PendingTxTTL = getEnvAsDuration(prefix+"PENDING_TX_TTL", c.PendingTxTTL)
+	return &chain
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -54,6 +195,24 @@ func getEnvAsUint(key string, defaultValue uint) uint {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsSlice(key string, defaultVal []string, sep string) []string {
 	if val := os.Getenv(key); val != "" {
 		parts := strings.Split(val, sep)
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ChainConfigs(t *testing.T) {
+	t.Run("single chain", func(t *testing.T) {
+		cfg := &Config{Chain: "ethereum", CheckpointFile: "checkpoint.txt"}
+		assert.Equal(t, []*Config{cfg}, cfg.ChainConfigs())
+	})
+
+	t.Run("chains with overrides", func(t *testing.T) {
+		t.Setenv("MAINNET_ETH_NODE_URL", "wss://mainnet.example")
+		t.Setenv("ARBITRUM_ONE_ETH_NODE_URLS", "wss://arb-a.example, wss://arb-b.example")
+		t.Setenv("ARBITRUM_ONE_CHAIN_ID", "42161")
+		t.Setenv("ARBITRUM_ONE_CONFIRMATIONS", "0")
+		t.Setenv("ARBITRUM_ONE_POLL_INTERVAL", "250ms")
+
+		cfg := &Config{
+			Chain:            "ethereum",
+			Chains:           []string{"mainnet", "arbitrum-one"},
+			EthereumNodeURLs: []string{"wss://default.example"},
+			CheckpointFile:   "checkpoint.txt",
+			Confirmations:    12,
+			PollInterval:     12 * time.Second,
+			QuorumNodeURLs:   []string{"wss://quorum.example"},
+		}
+		chains := cfg.ChainConfigs()
+		require.Len(t, chains, 2)
+
+		mainnet := chains[0]
+		assert.Equal(t, "mainnet", mainnet.Chain)
+		assert.Equal(t, []string{"wss://mainnet.example"}, mainnet.EthereumNodeURLs)
+		assert.Equal(t, "checkpoint-mainnet.txt", mainnet.CheckpointFile)
+		assert.Equal(t, "watched-addresses-mainnet.csv", mainnet.WatchedAddressesFile)
+		assert.Equal(t, uint(12), mainnet.Confirmations)
+		assert.Empty(t, mainnet.QuorumNodeURLs)
+
+		arbitrum := chains[1]
+		assert.Equal(t, "arbitrum-one", arbitrum.Chain)
+		assert.Equal(t, uint(42161), arbitrum.ChainID)
+		assert.Equal(t, []string{"wss://arb-a.example", "wss://arb-b.example"}, arbitrum.EthereumNodeURLs)
+		assert.Equal(t, "checkpoint-arbitrum-one.txt", arbitrum.CheckpointFile)
+		assert.Equal(t, uint(0), arbitrum.Confirmations)
+		assert.Equal(t, 250*time.Millisecond, arbitrum.PollInterval)
+	})
+}
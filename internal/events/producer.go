@@ -9,6 +9,11 @@ import (
 	kafka "github.com/segmentio/kafka-go"
 )
 
+// Publisher publishes events to consumers
+type Publisher interface {
+	PublishEvent(event interface{})
+}
+
 // KafkaProducer wraps a kafka.Writer and provides a simple interface to publish events
 type KafkaProducer struct {
 	writer *kafka.Writer
@@ -96,3 +101,6 @@ func createTopicIfNotExists(broker, topic string, log logger.Logger) error {
 	log.Infof("Created topic %s", topic)
 	return nil
 }
+
+// Ensure KafkaProducer conforms to the Publisher interface.
+var _ Publisher = (*KafkaProducer)(nil)
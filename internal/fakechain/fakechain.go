@@ -0,0 +1,726 @@
+// Package fakechain implements a scriptable in-memory chain for testing the scanner
+package fakechain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+)
+
+// BlockTime is the number of seconds between two fake blocks
+const BlockTime = 12
+
+// Chain is an in-memory chain implementing chain.ChainClient. Blocks are
+// appended on top of the canonical head; Fork rewinds the head so the next
+// appended blocks form a competing branch.
+type Chain struct {
+	mu        sync.Mutex
+	chainID   *big.Int
+	canonical []*types.Block
+	blocks    map[common.Hash]*types.Block
+	receipts  map[common.Hash]*types.Receipt
+	failing   map[common.Hash]bool
+	logs      map[common.Hash][]*types.Log
+	traces    map[common.Hash]chain.CallFrame
+	noTracing bool
+	safe      uint64
+	finalized uint64
+	forks     byte
+	coinbase  common.Address
+	calls     map[string][]byte
+	callCount int
+	callErr   error
+	headerErr error
+	latency   time.Duration
+	blockLag  map[uint64]time.Duration
+	blockErrs map[uint64]error
+	subs      map[*subscription[*types.Header]]struct{}
+	pending   map[*subscription[*types.Transaction]]struct{}
+}
+
+// New creates a chain containing only a genesis block
+func New(chainID int64) *Chain {
+	c := &Chain{
+		chainID:   big.NewInt(chainID),
+		blocks:    make(map[common.Hash]*types.Block),
+		receipts:  make(map[common.Hash]*types.Receipt),
+		failing:   make(map[common.Hash]bool),
+		logs:      make(map[common.Hash][]*types.Log),
+		traces:    make(map[common.Hash]chain.CallFrame),
+		calls:     make(map[string][]byte),
+		blockLag:  make(map[uint64]time.Duration),
+		blockErrs: make(map[uint64]error),
+		subs:      make(map[*subscription[*types.Header]]struct{}),
+		pending:   make(map[*subscription[*types.Transaction]]struct{}),
+	}
+
+	genesis := types.NewBlockWithHeader(&types.Header{
+		Number:   big.NewInt(0),
+		GasLimit: 30_000_000,
+		BaseFee:  big.NewInt(1_000_000_000),
+	})
+	c.canonical = append(c.canonical, genesis)
+	c.blocks[genesis.Hash()] = genesis
+	return c
+}
+
+// AppendBlock mines a block with the given transactions on top of the
+// canonical head and notifies new head subscribers
+func (c *Chain) AppendBlock(txs ...*types.Transaction) *types.Block {
+	return c.AppendBlockWithBody(types.Body{Transactions: txs})
+}
+
+// AppendBlockWithBody mines a block with the given body on top of the canonical
+// head and notifies new head subscribers. Every transaction gets a successful
+// receipt carrying the logs added with AddLogs, unless it was marked with
+// FailTransaction.
+func (c *Chain) AppendBlockWithBody(body types.Body) *types.Block {
+	c.mu.Lock()
+	parent := c.canonical[len(c.canonical)-1]
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + BlockTime,
+		BaseFee:    parent.BaseFee(),
+		Extra:      []byte{c.forks},
+		Coinbase:   c.coinbase,
+	}
+	if body.Withdrawals != nil {
+		header.WithdrawalsHash = &types.EmptyWithdrawalsHash
+	}
+
+	var gasUsed uint64
+	var logIndex uint
+	receipts := make([]*types.Receipt, len(body.Transactions))
+	for i, tx := range body.Transactions {
+		status := types.ReceiptStatusSuccessful
+		var logs []*types.Log
+		if c.failing[tx.Hash()] {
+			status = types.ReceiptStatusFailed
+		} else {
+			for _, pending := range c.logs[tx.Hash()] {
+				log := *pending
+				log.TxHash = tx.Hash()
+				log.TxIndex = uint(i)
+				log.BlockNumber = header.Number.Uint64()
+				log.Index = logIndex
+				logIndex++
+				logs = append(logs, &log)
+			}
+		}
+		gasUsed += tx.Gas()
+		var contractAddress common.Address
+		if tx.To() == nil {
+			from, _ := types.Sender(types.LatestSignerForChainID(c.chainID), tx)
+			contractAddress = crypto.CreateAddress(from, tx.Nonce())
+		}
+		receipts[i] = &types.Receipt{
+			Type:              tx.Type(),
+			Status:            status,
+			CumulativeGasUsed: gasUsed,
+			TxHash:            tx.Hash(),
+			ContractAddress:   contractAddress,
+			GasUsed:           tx.Gas(),
+			EffectiveGasPrice: effectiveGasPrice(tx, header.BaseFee),
+			BlockNumber:       new(big.Int).Set(header.Number),
+			TransactionIndex:  uint(i),
+			Logs:              logs,
+		}
+		if tx.Type() == types.BlobTxType {
+			receipts[i].BlobGasUsed = tx.BlobGas()
+			receipts[i].BlobGasPrice = tx.BlobGasFeeCap()
+		}
+		for _, log := range logs {
+			header.Bloom.Add(log.Address.Bytes())
+			for _, topic := range log.Topics {
+				header.Bloom.Add(topic.Bytes())
+			}
+		}
+	}
+
+	block := types.NewBlockWithHeader(header).WithBody(body)
+	for _, receipt := range receipts {
+		receipt.BlockHash = block.Hash()
+		for _, log := range receipt.Logs {
+			log.BlockHash = block.Hash()
+		}
+		c.receipts[receipt.TxHash] = receipt
+	}
+
+	c.canonical = append(c.canonical, block)
+	c.blocks[block.Hash()] = block
+	subs := c.subscribers()
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.send(block.Header())
+	}
+	return block
+}
+
+// Fork rewinds the canonical head to number. Blocks appended afterwards build
+// a new branch with different hashes, the orphaned blocks stay retrievable by hash.
+func (c *Chain) Fork(number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if number < uint64(len(c.canonical)) {
+		c.canonical = c.canonical[:number+1]
+	}
+	c.forks++
+}
+
+// FailTransaction makes a transaction revert when it is mined
+func (c *Chain) FailTransaction(txHash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failing[txHash] = true
+}
+
+// AddLogs makes a transaction emit logs when it is mined. The block, transaction
+// and index fields of the logs are filled in at mining time.
+func (c *Chain) AddLogs(txHash common.Hash, logs ...*types.Log) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logs[txHash] = append(c.logs[txHash], logs...)
+}
+
+// SetTrace sets the call trace returned for a transaction. Transactions
+// without a trace are traced as a single call without nested calls.
+func (c *Chain) SetTrace(txHash common.Hash, frame chain.CallFrame) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.traces[txHash] = frame
+}
+
+// SetReceiptLatency delays every TransactionReceipt answer, so concurrently
+// fetched blocks finish matching out of order like against a remote node
+func (c *Chain) SetReceiptLatency(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latency = latency
+}
+
+// SetBlockLatency delays every answer with block number, so blocks fetched
+// concurrently finish out of order
+func (c *Chain) SetBlockLatency(number uint64, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.blockLag[number] = latency
+}
+
+// FailBlock makes every request for block number fail with err, until it is
+// called again with nil
+func (c *Chain) FailBlock(number uint64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.blockErrs, number)
+		return
+	}
+	c.blockErrs[number] = err
+}
+
+// DisableTracing makes the chain behave like a node without the debug namespace
+func (c *Chain) DisableTracing() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.noTracing = true
+}
+
+// SetCoinbase sets the fee recipient of the blocks appended afterwards
+func (c *Chain) SetCoinbase(coinbase common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.coinbase = coinbase
+}
+
+// SetCall sets the data returned by calls to contract with input. Calls that
+// were not set revert like calls to a missing method.
+func (c *Chain) SetCall(contract common.Address, input, result []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls[callKey(contract, input)] = result
+}
+
+// FailCalls makes every contract call fail with err like a node unable to
+// execute it, until it is called again with nil
+func (c *Chain) FailCalls(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.callErr = err
+}
+
+// FailHeaderByHash makes every HeaderByHash request fail with err like a node
+// that cannot serve it, until it is called again with nil
+func (c *Chain) FailHeaderByHash(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headerErr = err
+}
+
+// CallCount returns the number of contract calls served
+func (c *Chain) CallCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.callCount
+}
+
+// SetSafe sets the block returned for the "safe" tag
+func (c *Chain) SetSafe(number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.safe = number
+}
+
+// SetFinalized sets the block returned for the "finalized" tag
+func (c *Chain) SetFinalized(number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.finalized = number
+}
+
+// DropSubscriptions fails every active new head subscription with err
+func (c *Chain) DropSubscriptions(err error) {
+	c.mu.Lock()
+	subs := c.subscribers()
+	c.subs = make(map[*subscription[*types.Header]]struct{})
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.fail(err)
+	}
+}
+
+// AnnouncePending delivers a transaction to the pending transaction subscribers
+// without mining it
+func (c *Chain) AnnouncePending(tx *types.Transaction) {
+	c.mu.Lock()
+	subs := make([]*subscription[*types.Transaction], 0, len(c.pending))
+	for sub := range c.pending {
+		subs = append(subs, sub)
+	}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.send(tx)
+	}
+}
+
+// Subscribers returns the number of active new head subscriptions
+func (c *Chain) Subscribers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.subs)
+}
+
+// PendingSubscribers returns the number of active pending transaction subscriptions
+func (c *Chain) PendingSubscribers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// Head returns the canonical head block
+func (c *Chain) Head() *types.Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.canonical[len(c.canonical)-1]
+}
+
+// ChainID implements chain.ChainClient
+func (c *Chain) ChainID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(c.chainID), nil
+}
+
+// BlockNumber implements chain.ChainClient
+func (c *Chain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.Head().NumberU64(), nil
+}
+
+// HeaderByNumber implements chain.ChainClient
+func (c *Chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	block, err := c.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+// HeaderByHash implements chain.ChainClient
+func (c *Chain) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	c.mu.Lock()
+	headerErr := c.headerErr
+	c.mu.Unlock()
+	if headerErr != nil {
+		return nil, headerErr
+	}
+
+	block, err := c.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+// BlockByNumber implements chain.ChainClient, supporting the latest, safe and finalized tags
+func (c *Chain) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	c.mu.Lock()
+	n := uint64(len(c.canonical) - 1)
+	if number != nil && number.Sign() >= 0 {
+		n = number.Uint64()
+	} else if number != nil {
+		switch rpc.BlockNumber(number.Int64()) {
+		case rpc.SafeBlockNumber:
+			n = c.safe
+		case rpc.FinalizedBlockNumber:
+			n = c.finalized
+		}
+	}
+
+	if n >= uint64(len(c.canonical)) {
+		c.mu.Unlock()
+		return nil, ethereum.NotFound
+	}
+	block := c.canonical[n]
+	c.mu.Unlock()
+
+	return c.serveBlock(block)
+}
+
+// BlockByHash implements chain.ChainClient, including blocks orphaned by a fork
+func (c *Chain) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	c.mu.Lock()
+	block, ok := c.blocks[hash]
+	c.mu.Unlock()
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return c.serveBlock(block)
+}
+
+// serveBlock answers with block after the latency set by SetBlockLatency, or
+// with the error set by FailBlock
+func (c *Chain) serveBlock(block *types.Block) (*types.Block, error) {
+	c.mu.Lock()
+	latency := c.blockLag[block.NumberU64()]
+	err := c.blockErrs[block.NumberU64()]
+	c.mu.Unlock()
+
+	time.Sleep(latency)
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// TransactionReceipt implements chain.ChainClient, answering after the latency set by SetReceiptLatency
+func (c *Chain) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	c.mu.Lock()
+	latency := c.latency
+	c.mu.Unlock()
+	time.Sleep(latency)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	receipt, ok := c.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// CallContract implements chain.ChainClient with the results set by SetCall
+func (c *Chain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.callCount++
+	if c.callErr != nil {
+		return nil, c.callErr
+	}
+	if msg.To == nil {
+		return nil, errors.New("contract address required")
+	}
+	result, ok := c.calls[callKey(*msg.To, msg.Data)]
+	if !ok {
+		return nil, revertError{}
+	}
+	return result, nil
+}
+
+func callKey(contract common.Address, input []byte) string {
+	return contract.Hex() + hexutil.Encode(input)
+}
+
+// revertError is the JSON-RPC error a node returns for a reverted call
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+// BlockReceipts implements chain.ChainClient for block hashes and numbers
+func (c *Chain) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var block *types.Block
+	var err error
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = c.BlockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block, err = c.BlockByNumber(ctx, big.NewInt(number.Int64()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, ethereum.NotFound
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		receipt, ok := c.receipts[tx.Hash()]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// FilterLogs implements chain.ChainClient for queries by block hash or by a
+// range of canonical blocks
+func (c *Chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var blocks []*types.Block
+	if q.BlockHash != nil {
+		block, ok := c.blocks[*q.BlockHash]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		blocks = append(blocks, block)
+	} else {
+		from, to := uint64(0), uint64(len(c.canonical)-1)
+		if q.FromBlock != nil {
+			from = q.FromBlock.Uint64()
+		}
+		if q.ToBlock != nil && q.ToBlock.Uint64() < to {
+			to = q.ToBlock.Uint64()
+		}
+		for n := from; n <= to; n++ {
+			blocks = append(blocks, c.canonical[n])
+		}
+	}
+
+	var logs []types.Log
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			receipt, ok := c.receipts[tx.Hash()]
+			if !ok || receipt.BlockHash != block.Hash() {
+				continue
+			}
+			for _, log := range receipt.Logs {
+				if matchesFilter(log, q) {
+					logs = append(logs, *log)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// TraceBlockByHash implements chain.BlockTracer, including blocks orphaned by a fork
+func (c *Chain) TraceBlockByHash(ctx context.Context, hash common.Hash) ([]chain.TxTrace, error) {
+	c.mu.Lock()
+	noTracing := c.noTracing
+	c.mu.Unlock()
+	if noTracing {
+		return nil, chain.ErrTracingUnsupported
+	}
+
+	block, err := c.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	signer := types.LatestSignerForChainID(c.chainID)
+	traces := make([]chain.TxTrace, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		frame, ok := c.traces[tx.Hash()]
+		if !ok {
+			from, _ := types.Sender(signer, tx)
+			frame = chain.CallFrame{
+				Type:  "CALL",
+				From:  from,
+				To:    tx.To(),
+				Value: (*hexutil.Big)(tx.Value()),
+			}
+		}
+		traces = append(traces, chain.TxTrace{TxHash: tx.Hash(), Result: frame})
+	}
+	return traces, nil
+}
+
+// SubscribeNewHead implements chain.ChainClient
+func (c *Chain) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub := newSubscription(ch)
+	sub.remove = func() {
+		c.mu.Lock()
+		delete(c.subs, sub)
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// SubscribePendingTransactions implements chain.PendingSubscriber
+func (c *Chain) SubscribePendingTransactions(ctx context.Context, ch chan<- *types.Transaction) (ethereum.Subscription, error) {
+	sub := newSubscription(ch)
+	sub.remove = func() {
+		c.mu.Lock()
+		delete(c.pending, sub)
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending[sub] = struct{}{}
+	return sub, nil
+}
+
+// subscribers returns the active new head subscriptions, callers must hold the lock
+func (c *Chain) subscribers() []*subscription[*types.Header] {
+	subs := make([]*subscription[*types.Header], 0, len(c.subs))
+	for sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// subscription delivers new heads or pending transactions to a single subscriber
+type subscription[T any] struct {
+	ch     chan<- T
+	err    chan error
+	quit   chan struct{}
+	once   sync.Once
+	remove func()
+}
+
+func newSubscription[T any](ch chan<- T) *subscription[T] {
+	return &subscription[T]{
+		ch:   ch,
+		err:  make(chan error, 1),
+		quit: make(chan struct{}),
+	}
+}
+
+// send blocks until the subscriber receives the value or unsubscribes
+func (s *subscription[T]) send(value T) {
+	select {
+	case s.ch <- value:
+	case <-s.quit:
+	}
+}
+
+func (s *subscription[T]) fail(err error) {
+	if err == nil {
+		err = errors.New("subscription dropped")
+	}
+	s.once.Do(func() {
+		s.err <- err
+		close(s.quit)
+	})
+}
+
+// Err implements ethereum.Subscription
+func (s *subscription[T]) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe implements ethereum.Subscription
+func (s *subscription[T]) Unsubscribe() {
+	s.remove()
+
+	s.once.Do(func() {
+		close(s.err)
+		close(s.quit)
+	})
+}
+
+// effectiveGasPrice returns the gas price a transaction pays at baseFee
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		return tx.GasPrice()
+	}
+	return tip.Add(tip, baseFee)
+}
+
+// matchesFilter reports whether a log matches the addresses and topics of a query
+func matchesFilter(log *types.Log, q ethereum.FilterQuery) bool {
+	if len(q.Addresses) > 0 && !slices.Contains(q.Addresses, log.Address) {
+		return false
+	}
+	if len(q.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) > 0 && !slices.Contains(alternatives, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ensure Chain conforms to the ChainClient interface.
+var _ chain.ChainClient = (*Chain)(nil)
+
+// Ensure Chain can trace blocks like a node serving the debug namespace.
+var _ chain.BlockTracer = (*Chain)(nil)
+
+// Ensure Chain can deliver pending transactions like a node's mempool.
+var _ chain.PendingSubscriber = (*Chain)(nil)
@@ -6,28 +6,164 @@ import (
 )
 
 var (
-	BlocksProcessed = promauto.NewCounter(prometheus.CounterOpts{
+	BlocksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "block_scanner_blocks_processed_total",
 		Help: "Total number of blocks processed",
-	})
+	}, []string{"chain"})
 
-	TransactionsProcessed = promauto.NewCounter(prometheus.CounterOpts{
+	TransactionsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "block_scanner_transactions_processed_total",
 		Help: "Total number of transactions processed",
-	})
+	}, []string{"chain"})
 
-	KafkaEventsPublished = promauto.NewCounter(prometheus.CounterOpts{
+	KafkaEventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "block_scanner_kafka_events_published_total",
 		Help: "Total number of events published to Kafka",
-	})
+	}, []string{"chain"})
 
-	Reconnections = promauto.NewCounter(prometheus.CounterOpts{
+	Reconnections = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "block_scanner_reconnections_total",
 		Help: "Total number of reconnections to Ethereum node",
-	})
+	}, []string{"chain"})
 
-	CurrentBlock = promauto.NewGauge(prometheus.GaugeOpts{
+	CurrentBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "block_scanner_current_block",
 		Help: "Current block number being processed",
-	})
+	}, []string{"chain"})
+
+	CatchUpBlocksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_catchup_blocks_processed_total",
+		Help: "Total number of blocks processed while catching up from the checkpoint",
+	}, []string{"chain"})
+
+	CatchUpRemainingBlocks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "block_scanner_catchup_remaining_blocks",
+		Help: "Number of blocks left to process before the scanner is in sync",
+	}, []string{"chain"})
+
+	Reorgs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_reorgs_total",
+		Help: "Total number of chain reorganizations detected",
+	}, []string{"chain"})
+
+	EventsRetracted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_events_retracted_total",
+		Help: "Total number of retracted events published for orphaned transactions",
+	}, []string{"chain"})
+
+	BackfillBlocksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_backfill_blocks_processed_total",
+		Help: "Total number of blocks processed by the backfill command",
+	}, []string{"chain"})
+
+	BackfillRemainingBlocks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "block_scanner_backfill_remaining_blocks",
+		Help: "Number of blocks left in the current backfill range",
+	}, []string{"chain"})
+
+	BlockFetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "block_scanner_block_fetch_duration_seconds",
+		Help:    "Time taken to fetch a block from the Ethereum node",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"chain"})
+
+	ProviderHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "block_scanner_provider_healthy",
+		Help: "Whether an Ethereum node provider passed its last health check",
+	}, []string{"chain", "provider"})
+
+	ProviderActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "block_scanner_provider_active",
+		Help: "Whether an Ethereum node provider is the one currently in use",
+	}, []string{"chain", "provider"})
+
+	ProviderHeadBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "block_scanner_provider_head_block",
+		Help: "Latest block number reported by an Ethereum node provider",
+	}, []string{"chain", "provider"})
+
+	ProviderErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_provider_errors_total",
+		Help: "Total number of errors returned by an Ethereum node provider",
+	}, []string{"chain", "provider"})
+
+	ProviderFailovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_provider_failovers_total",
+		Help: "Total number of switches of the active Ethereum node provider",
+	}, []string{"chain"})
+
+	QuorumDisagreements = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_quorum_disagreements_total",
+		Help: "Total number of blocks for which a quorum provider reported a different hash",
+	}, []string{"chain", "provider"})
+
+	QuorumFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_quorum_failures_total",
+		Help: "Total number of blocks held back because not enough providers agreed on the hash",
+	}, []string{"chain"})
+
+	FailedTransactionsSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_failed_transactions_skipped_total",
+		Help: "Total number of matched transactions not published because they reverted",
+	}, []string{"chain"})
+
+	TokenTransfersDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_token_transfers_detected_total",
+		Help: "Total number of ERC-20 transfers detected for monitored addresses",
+	}, []string{"chain"})
+
+	NFTTransfersDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_nft_transfers_detected_total",
+		Help: "Total number of ERC-721 and ERC-1155 transfers detected for monitored addresses",
+	}, []string{"chain"})
+
+	InternalTransfersDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_internal_transfers_detected_total",
+		Help: "Total number of internal ETH transfers detected for monitored addresses by call tracing",
+	}, []string{"chain"})
+
+	PendingTransactionsDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_pending_transactions_detected_total",
+		Help: "Total number of pending events published for mempool transactions of monitored addresses",
+	}, []string{"chain"})
+
+	PendingTransactionsExpired = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_pending_transactions_expired_total",
+		Help: "Total number of pending events expired because the transaction was not mined in time",
+	}, []string{"chain"})
+
+	PendingTransactionsTracked = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "block_scanner_pending_transactions_tracked",
+		Help: "Number of matched pending transactions currently tracked for deduplication and expiry",
+	}, []string{"chain"})
+
+	LifecycleEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_lifecycle_events_total",
+		Help: "Total number of events published again as their block reached a lifecycle state",
+	}, []string{"chain", "state"})
+
+	WithdrawalsDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_withdrawals_detected_total",
+		Help: "Total number of beacon chain withdrawals detected for monitored addresses",
+	}, []string{"chain"})
+
+	BlockRewardsDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_block_rewards_detected_total",
+		Help: "Total number of priority fee and MEV payment rewards detected for monitored fee recipients",
+	}, []string{"chain"})
+
+	DelegationsDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_delegations_detected_total",
+		Help: "Total number of EIP-7702 code delegations and resets signed by monitored addresses",
+	}, []string{"chain"})
+
+	TokenMetadataResolved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_token_metadata_resolved_total",
+		Help: "Total number of token contracts whose metadata was resolved and cached",
+	}, []string{"chain"})
+
+	PricesUnavailable = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "block_scanner_prices_unavailable_total",
+		Help: "Total number of events published without a USD value because no price was available",
+	}, []string{"chain"})
 )
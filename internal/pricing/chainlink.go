@@ -0,0 +1,113 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Selectors of the Chainlink AggregatorV3Interface methods used
+var (
+	decimalsSelector        = crypto.Keccak256([]byte("decimals()"))[:4]
+	latestRoundDataSelector = crypto.Keccak256([]byte("latestRoundData()"))[:4]
+)
+
+// ChainlinkSource reads USD prices from Chainlink aggregators with eth_call at
+// the block of the event, so events are valued at the price on chain at the time
+type ChainlinkSource struct {
+	caller   ethereum.ContractCaller
+	feeds    map[string]common.Address
+	mu       sync.Mutex
+	decimals map[common.Address]uint8
+}
+
+// NewChainlinkSource creates a source reading the aggregators of feeds, which
+// are asset:aggregator entries such as native:0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419
+func NewChainlinkSource(caller ethereum.ContractCaller, feeds []string) (*ChainlinkSource, error) {
+	source := &ChainlinkSource{
+		caller:   caller,
+		feeds:    make(map[string]common.Address, len(feeds)),
+		decimals: make(map[common.Address]uint8),
+	}
+	for _, feed := range feeds {
+		asset, aggregator, ok := strings.Cut(feed, ":")
+		if !ok || asset == "" || !common.IsHexAddress(aggregator) {
+			return nil, fmt.Errorf("invalid Chainlink feed %q, expected asset:aggregator", feed)
+		}
+		source.feeds[strings.ToLower(asset)] = common.HexToAddress(aggregator)
+	}
+	return source, nil
+}
+
+// Name implements PriceSource
+func (c *ChainlinkSource) Name() string { return "chainlink" }
+
+// Price implements PriceSource
+func (c *ChainlinkSource) Price(ctx context.Context, asset string, blockNumber uint64) (*big.Float, error) {
+	aggregator, ok := c.feeds[asset]
+	if !ok {
+		return nil, ErrNoPrice
+	}
+	block := new(big.Int).SetUint64(blockNumber)
+
+	decimals, err := c.aggregatorDecimals(ctx, aggregator, block)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.call(ctx, aggregator, latestRoundDataSelector, block)
+	if err != nil {
+		return nil, err
+	}
+	// latestRoundData returns (roundId, answer, startedAt, updatedAt, answeredInRound)
+	if len(data) < 5*32 {
+		return nil, fmt.Errorf("invalid latestRoundData of %s", aggregator.Hex())
+	}
+	// A negative or zero answer is not a usable price
+	answer := new(big.Int).SetBytes(data[32:64])
+	if data[32]&0x80 != 0 || answer.Sign() == 0 {
+		return nil, ErrNoPrice
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	price := new(big.Float).SetPrec(precision).SetInt(answer)
+	return price.Quo(price, new(big.Float).SetPrec(precision).SetInt(scale)), nil
+}
+
+// aggregatorDecimals returns the decimals of an aggregator's answer, which never change
+func (c *ChainlinkSource) aggregatorDecimals(ctx context.Context, aggregator common.Address, block *big.Int) (uint8, error) {
+	c.mu.Lock()
+	decimals, ok := c.decimals[aggregator]
+	c.mu.Unlock()
+	if ok {
+		return decimals, nil
+	}
+
+	data, err := c.call(ctx, aggregator, decimalsSelector, block)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 32 || new(big.Int).SetBytes(data[:32]).Cmp(big.NewInt(255)) > 0 {
+		return 0, fmt.Errorf("invalid decimals of %s", aggregator.Hex())
+	}
+	decimals = data[31]
+
+	c.mu.Lock()
+	c.decimals[aggregator] = decimals
+	c.mu.Unlock()
+	return decimals, nil
+}
+
+func (c *ChainlinkSource) call(ctx context.Context, aggregator common.Address, selector []byte, block *big.Int) ([]byte, error) {
+	data, err := c.caller.CallContract(ctx, ethereum.CallMsg{To: &aggregator, Data: selector}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call aggregator %s: %v", aggregator.Hex(), err)
+	}
+	return data, nil
+}
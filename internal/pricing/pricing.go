@@ -0,0 +1,54 @@
+// Package pricing implements USD price sources used to value detected transfers
+package pricing
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// NativeAsset is the asset key of the chain's native currency. Tokens are keyed
+// by their lowercase contract address.
+const NativeAsset = "native"
+
+// precision of the big.Float arithmetic used for prices and amounts
+const precision = 256
+
+// ErrNoPrice is returned when a source has no price for an asset
+var ErrNoPrice = errors.New("no price available")
+
+// PriceSource returns the USD price of one whole unit of an asset at a block.
+// Sources that only know current prices ignore the block number.
+type PriceSource interface {
+	Name() string
+	Price(ctx context.Context, asset string, blockNumber uint64) (*big.Float, error)
+}
+
+// Value returns the USD value of a raw amount of an asset with the given
+// decimals, rounded to cents
+func Value(amount *big.Int, decimals uint8, price *big.Float) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value := new(big.Float).SetPrec(precision).SetInt(amount)
+	value.Quo(value, new(big.Float).SetPrec(precision).SetInt(scale))
+	value.Mul(value, price)
+	return value.Text('f', 2)
+}
+
+// FormatPrice returns a price as a decimal string without trailing zeros
+func FormatPrice(price *big.Float) string {
+	text := price.Text('f', 8)
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	return text
+}
+
+// parsePrice parses a decimal USD price
+func parsePrice(value string) (*big.Float, bool) {
+	price, ok := new(big.Float).SetPrec(precision).SetString(strings.TrimSpace(value))
+	if !ok || price.Sign() <= 0 {
+		return nil, false
+	}
+	return price, true
+}
@@ -0,0 +1,88 @@
+package pricing_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/pricing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricing_Value(t *testing.T) {
+	price, _ := new(big.Float).SetString("2000.5")
+	wei, _ := new(big.Int).SetString("1500000000000000000", 10)
+	assert.Equal(t, "3000.75", pricing.Value(wei, 18, price))
+	assert.Equal(t, "2000.5", pricing.FormatPrice(price))
+	assert.Equal(t, "1", pricing.FormatPrice(big.NewFloat(1)))
+}
+
+func TestPricing_FileSource(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "prices.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{"native": "3500.25", "0xA0B86991C6218B36C1D19D4A2E9EB0CE3606EB48": "1"}`), 0644))
+
+	source, err := pricing.NewFileSource(filename)
+	require.NoError(t, err)
+
+	price, err := source.Price(context.Background(), pricing.NativeAsset, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "3500.25", pricing.FormatPrice(price))
+
+	price, err = source.Price(context.Background(), "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1", pricing.FormatPrice(price))
+
+	_, err = source.Price(context.Background(), "0x0000000000000000000000000000000000000001", 1)
+	assert.ErrorIs(t, err, pricing.ErrNoPrice)
+
+	require.NoError(t, os.WriteFile(filename, []byte(`{"native": "free"}`), 0644))
+	_, err = pricing.NewFileSource(filename)
+	assert.Error(t, err)
+}
+
+// roundData returns the encoded latestRoundData of an aggregator answer
+func roundData(answer *big.Int) []byte {
+	data := make([]byte, 0, 5*32)
+	data = append(data, common.BigToHash(big.NewInt(1)).Bytes()...)
+	data = append(data, common.BigToHash(answer).Bytes()...)
+	data = append(data, make([]byte, 3*32)...)
+	return data
+}
+
+func TestPricing_ChainlinkSource(t *testing.T) {
+	ethFeed := common.HexToAddress("0x5f4ec3df9cbd43714fe2740f5e3616155c5b8419")
+	brokenFeed := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	decimals := crypto.Keccak256([]byte("decimals()"))[:4]
+	latestRoundData := crypto.Keccak256([]byte("latestRoundData()"))[:4]
+
+	fake := fakechain.New(1)
+	fake.SetCall(ethFeed, decimals, common.BigToHash(big.NewInt(8)).Bytes())
+	fake.SetCall(ethFeed, latestRoundData, roundData(big.NewInt(350025000000)))
+	fake.SetCall(brokenFeed, decimals, common.BigToHash(big.NewInt(8)).Bytes())
+	fake.SetCall(brokenFeed, latestRoundData, roundData(big.NewInt(0)))
+
+	source, err := pricing.NewChainlinkSource(fake, []string{
+		"native:" + ethFeed.Hex(),
+		"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48:" + brokenFeed.Hex(),
+	})
+	require.NoError(t, err)
+
+	price, err := source.Price(context.Background(), pricing.NativeAsset, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "3500.25", pricing.FormatPrice(price))
+
+	_, err = source.Price(context.Background(), "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", 1)
+	assert.ErrorIs(t, err, pricing.ErrNoPrice)
+
+	_, err = source.Price(context.Background(), "0x0000000000000000000000000000000000000001", 1)
+	assert.ErrorIs(t, err, pricing.ErrNoPrice)
+
+	_, err = pricing.NewChainlinkSource(fake, []string{"native"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,259 @@
+// Package rpcpool implements a prioritized pool of Ethereum RPC providers with failover
+package rpcpool
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/logger"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+)
+
+// Provider is a single Ethereum node in the pool
+type Provider struct {
+	URL    string
+	Name   string // Label used in logs and metrics, never contains credentials
+	Client chain.ChainClient
+
+	healthy bool
+	head    uint64
+}
+
+// Pool keeps the providers ordered by priority and tracks which one is active
+type Pool struct {
+	mu        sync.RWMutex
+	chain     string
+	providers []*Provider
+	active    int
+	maxLag    uint64
+	changes   chan struct{}
+	dial      chain.DialFunc
+	logger    logger.Logger
+}
+
+// New dials every node URL of a chain, in priority order. It fails only when no provider can be dialed.
+func New(ctx context.Context, logger logger.Logger, chainName string, urls []string, maxLag uint64, dial chain.DialFunc) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no Ethereum node URLs configured for chain %s", chainName)
+	}
+
+	p := &Pool{
+		chain:   chainName,
+		maxLag:  maxLag,
+		changes: make(chan struct{}, 1),
+		dial:    dial,
+		logger:  logger,
+	}
+
+	dialed := 0
+	for i, rawURL := range urls {
+		provider := &Provider{
+			URL:  rawURL,
+			Name: ProviderName(rawURL, i),
+		}
+		client, err := dial(ctx, rawURL)
+		if err != nil {
+			logger.Warnw("Failed to connect to provider", "provider", provider.Name, "error", err)
+			metrics.ProviderErrors.WithLabelValues(p.chain, provider.Name).Inc()
+		} else {
+			provider.Client = client
+			provider.healthy = true
+			dialed++
+		}
+		metrics.ProviderHealthy.WithLabelValues(p.chain, provider.Name).Set(boolToFloat(provider.healthy))
+		p.providers = append(p.providers, provider)
+	}
+	if dialed == 0 {
+		return nil, fmt.Errorf("failed to connect to any of %d Ethereum nodes", len(urls))
+	}
+
+	p.active = selectActive(p.providers, p.active)
+	p.setActiveMetric()
+	return p, nil
+}
+
+// Active returns a snapshot of the provider currently used for all requests
+func (p *Pool) Active() Provider {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return *p.providers[p.active]
+}
+
+// CallContract executes a call on the active provider, which lets components
+// outside the scanner follow failovers
+func (p *Pool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return p.Active().Client.CallContract(ctx, msg, blockNumber)
+}
+
+// Changes is signalled whenever the active provider changes
+func (p *Pool) Changes() <-chan struct{} {
+	return p.changes
+}
+
+// MarkFailed marks a provider unhealthy after an error and fails over if it was active.
+// The provider becomes eligible again once it passes a health check.
+func (p *Pool) MarkFailed(name string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.logger.Warnw("Provider failed", "provider", name, "error", err)
+	metrics.ProviderErrors.WithLabelValues(p.chain, name).Inc()
+	for _, provider := range p.providers {
+		if provider.Name == name {
+			provider.healthy = false
+		}
+	}
+	metrics.ProviderHealthy.WithLabelValues(p.chain, name).Set(0)
+
+	p.reselect()
+}
+
+// Run health checks every provider on the interval until ctx is cancelled
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkHealth(ctx)
+		}
+	}
+}
+
+// checkHealth refreshes the head of every provider and marks providers that
+// error or fall more than maxLag blocks behind the best head as unhealthy
+func (p *Pool) checkHealth(ctx context.Context) {
+	p.mu.RLock()
+	providers := append([]*Provider(nil), p.providers...)
+	p.mu.RUnlock()
+
+	heads := make([]uint64, len(providers))
+	errs := make([]error, len(providers))
+	clients := make([]chain.ChainClient, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider *Provider) {
+			defer wg.Done()
+
+			clients[i] = provider.Client
+			if clients[i] == nil {
+				clients[i], errs[i] = p.dial(ctx, provider.URL)
+				if errs[i] != nil {
+					return
+				}
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			heads[i], errs[i] = clients[i].BlockNumber(checkCtx)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var best uint64
+	for i := range providers {
+		if errs[i] == nil && heads[i] > best {
+			best = heads[i]
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, provider := range providers {
+		if provider.Client == nil && clients[i] != nil {
+			provider.Client = clients[i]
+		}
+
+		wasHealthy := provider.healthy
+		if errs[i] != nil {
+			provider.healthy = false
+			metrics.ProviderErrors.WithLabelValues(p.chain, provider.Name).Inc()
+		} else {
+			provider.head = heads[i]
+			provider.healthy = best-heads[i] <= p.maxLag
+			metrics.ProviderHeadBlock.WithLabelValues(p.chain, provider.Name).Set(float64(heads[i]))
+		}
+		metrics.ProviderHealthy.WithLabelValues(p.chain, provider.Name).Set(boolToFloat(provider.healthy))
+
+		if wasHealthy != provider.healthy {
+			p.logger.Infow("Provider health changed",
+				"provider", provider.Name,
+				"healthy", provider.healthy,
+				"head", provider.head,
+				"best_head", best,
+				"error", errs[i],
+			)
+		}
+	}
+
+	p.reselect()
+}
+
+// reselect picks the active provider, callers must hold the write lock
+func (p *Pool) reselect() {
+	next := selectActive(p.providers, p.active)
+	if next == p.active {
+		return
+	}
+
+	p.logger.Warnw("Failing over to another provider",
+		"from", p.providers[p.active].Name,
+		"to", p.providers[next].Name,
+	)
+	metrics.ProviderFailovers.WithLabelValues(p.chain).Inc()
+
+	p.active = next
+	p.setActiveMetric()
+
+	select {
+	case p.changes <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Pool) setActiveMetric() {
+	for i, provider := range p.providers {
+		metrics.ProviderActive.WithLabelValues(p.chain, provider.Name).Set(boolToFloat(i == p.active))
+	}
+}
+
+// selectActive returns the highest priority healthy provider. When no provider
+// is healthy the current one is kept, as there is nothing better to switch to.
+func selectActive(providers []*Provider, current int) int {
+	for i, provider := range providers {
+		if provider.healthy && provider.Client != nil {
+			return i
+		}
+	}
+	return current
+}
+
+// ProviderName derives a metrics label from the node URL without leaking
+// API keys that providers commonly put in the path or query
+func ProviderName(rawURL string, index int) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Sprintf("provider-%d", index)
+	}
+	return fmt.Sprintf("%d-%s", index, u.Hostname())
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
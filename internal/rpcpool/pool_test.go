@@ -0,0 +1,150 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPool creates a pool of a primary and a secondary fake chain with a
+// maximum lag of 5 blocks
+func newTestPool(t *testing.T) (*Pool, *fakechain.Chain, *fakechain.Chain) {
+	primary, secondary := fakechain.New(1), fakechain.New(1)
+	chains := map[string]*fakechain.Chain{"fake://primary": primary, "fake://secondary": secondary}
+	dial := func(ctx context.Context, rawURL string) (chain.ChainClient, error) {
+		return chains[rawURL], nil
+	}
+
+	pool, err := New(context.Background(), logger.NewNoOpLogger(), "ethereum",
+		[]string{"fake://primary", "fake://secondary"}, 5, dial)
+	require.NoError(t, err)
+	require.Equal(t, "0-primary", pool.Active().Name)
+	return pool, primary, secondary
+}
+
+// changed reports whether the pool signalled a change of the active provider
+func changed(pool *Pool) bool {
+	select {
+	case <-pool.Changes():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPool_SelectActive(t *testing.T) {
+	client := fakechain.New(1)
+
+	tests := []struct {
+		name      string
+		providers []*Provider
+		current   int
+		expected  int
+	}{
+		{
+			name: "highest priority healthy provider wins",
+			providers: []*Provider{
+				{Client: client, healthy: true},
+				{Client: client, healthy: true},
+			},
+			current:  1,
+			expected: 0,
+		},
+		{
+			name: "skips unhealthy providers",
+			providers: []*Provider{
+				{Client: client, healthy: false},
+				{Client: client, healthy: true},
+			},
+			current:  0,
+			expected: 1,
+		},
+		{
+			name: "skips providers that never connected",
+			providers: []*Provider{
+				{Client: nil, healthy: true},
+				{Client: client, healthy: true},
+			},
+			current:  0,
+			expected: 1,
+		},
+		{
+			name: "keeps current provider when none is healthy",
+			providers: []*Provider{
+				{Client: client, healthy: false},
+				{Client: client, healthy: false},
+			},
+			current:  1,
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, selectActive(tt.providers, tt.current))
+		})
+	}
+}
+
+func TestPool_ProviderName(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		index    int
+		expected string
+	}{
+		{"strips api key from path", "wss://eth-mainnet.g.alchemy.com/v2/secret", 0, "0-eth-mainnet.g.alchemy.com"},
+		{"strips port and query", "http://localhost:8545?key=secret", 1, "1-localhost"},
+		{"falls back for ipc paths", "/tmp/geth.ipc", 2, "provider-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ProviderName(tt.url, tt.index))
+		})
+	}
+}
+
+func TestPool_CheckHealthMarksLaggingProvider(t *testing.T) {
+	pool, primary, secondary := newTestPool(t)
+	for range 10 {
+		secondary.AppendBlock()
+	}
+	primary.AppendBlock()
+
+	// The primary is 9 blocks behind the best head
+	pool.checkHealth(context.Background())
+	assert.Equal(t, "1-secondary", pool.Active().Name)
+	assert.True(t, changed(pool))
+
+	// Within the maximum lag the primary is preferred again
+	for range 5 {
+		primary.AppendBlock()
+	}
+	pool.checkHealth(context.Background())
+	assert.Equal(t, "0-primary", pool.Active().Name)
+	assert.Equal(t, uint64(6), pool.Active().head)
+	assert.True(t, changed(pool))
+}
+
+func TestPool_MarkFailedSignalsChanges(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+
+	// Failures of a standby provider do not change the active one
+	pool.MarkFailed("1-secondary", errors.New("timeout"))
+	assert.Equal(t, "0-primary", pool.Active().Name)
+	assert.False(t, changed(pool))
+
+	// The standby comes back with the next health check
+	pool.checkHealth(context.Background())
+	pool.MarkFailed("0-primary", errors.New("connection reset"))
+	assert.Equal(t, "1-secondary", pool.Active().Name)
+	assert.True(t, changed(pool))
+	assert.False(t, changed(pool), "a failover should be signalled once")
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+)
+
+// monitoredParty is a party of a transfer that is a monitored address
+type monitoredParty struct {
+	address string
+	userID  string
+}
+
+// userID returns the user monitoring an address
+func (s *Scanner) userID(address string) (string, bool) {
+	s.addressMu.RLock()
+	defer s.addressMu.RUnlock()
+
+	userID, ok := s.addressMap[address]
+	return userID, ok
+}
+
+// monitored returns the user of an address, using the bloom filter as the pre-check
+func (s *Scanner) monitored(address string) (string, bool) {
+	if !s.bloomFilter.Test(address) {
+		return "", false
+	}
+	return s.userID(address)
+}
+
+// monitoredParties returns the distinct parties of a transfer that are in the
+// address map, using the bloom filter as the pre-check
+func (s *Scanner) monitoredParties(from, to string) []monitoredParty {
+	parties := []string{from}
+	if to != from {
+		parties = append(parties, to)
+	}
+
+	var monitored []monitoredParty
+	for _, address := range s.bloomFilter.BatchTest(parties) {
+		if userID, ok := s.userID(address); ok {
+			monitored = append(monitored, monitoredParty{address: address, userID: userID})
+		}
+	}
+	return monitored
+}
+
+// counterpartyUserID returns the user of the other monitored party of a transfer,
+// or "" when the counterparty is not monitored or the transfer is to self
+func counterpartyUserID(parties []monitoredParty, party monitoredParty) string {
+	for _, other := range parties {
+		if other.address != party.address {
+			return other.userID
+		}
+	}
+	return ""
+}
+
+// watchAddress starts monitoring an address for a user and appends it to the
+// watched addresses file so it stays monitored after a restart
+func (s *Scanner) watchAddress(address, userID string) {
+	s.addressMu.Lock()
+	if _, ok := s.addressMap[address]; ok {
+		s.addressMu.Unlock()
+		return
+	}
+	s.addressMap[address] = userID
+	s.addressVersion.Add(1)
+	s.addressMu.Unlock()
+
+	s.bloomFilter.Add(address)
+	s.logger.Infow("Watching new address", "address", address, "userId", userID)
+
+	if s.addressesFile == "" {
+		return
+	}
+	if err := storage.AppendAddress(s.addressesFile, userID, address); err != nil {
+		s.logger.Errorf("Failed to save watched address %s: %v", address, err)
+	}
+}
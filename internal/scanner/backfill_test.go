@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_Backfill(t *testing.T) {
+	tests := []struct {
+		name           string
+		from, to       uint64
+		hasCheckpoint  bool
+		checkpoint     uint64
+		blocks         []uint64
+		processed      int
+		lastCheckpoint uint64
+	}{
+		{name: "without checkpoint", from: 2, to: 4, blocks: []uint64{2, 3, 4}, processed: 3, lastCheckpoint: 4},
+		{name: "from genesis without checkpoint", from: 0, to: 2, blocks: []uint64{1, 2}, processed: 3, lastCheckpoint: 2},
+		{name: "resumes from checkpoint", from: 1, to: 4, hasCheckpoint: true, checkpoint: 2, blocks: []uint64{3, 4}, processed: 2, lastCheckpoint: 4},
+		{name: "resumes from genesis checkpoint", from: 0, to: 2, hasCheckpoint: true, checkpoint: 0, blocks: []uint64{1, 2}, processed: 2, lastCheckpoint: 2},
+		{name: "checkpoint after the range", from: 1, to: 3, hasCheckpoint: true, checkpoint: 10, blocks: []uint64{1, 2, 3}, processed: 3, lastCheckpoint: 3},
+		{name: "checkpoint before the range", from: 3, to: 4, hasCheckpoint: true, checkpoint: 1, blocks: []uint64{3, 4}, processed: 2, lastCheckpoint: 4},
+		{name: "finished range", from: 1, to: 4, hasCheckpoint: true, checkpoint: 4, lastCheckpoint: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, fakechain.New(1), newTestConfig(t))
+			for range 4 {
+				env.chain.AppendBlock(env.transferToUser(t, 1))
+			}
+
+			checkpointFile := filepath.Join(t.TempDir(), "backfill.txt")
+			if tt.hasCheckpoint {
+				require.NoError(t, storage.WriteLastProcessedBlock(checkpointFile, tt.checkpoint))
+			}
+
+			// Block 0 has no transactions, so whether it was processed shows in the metric only
+			processed := metrics.BackfillBlocksProcessed.WithLabelValues(env.scanner.chainName)
+			before := testutil.ToFloat64(processed)
+			require.NoError(t, env.scanner.Backfill(tt.from, tt.to, checkpointFile))
+			assert.Equal(t, float64(tt.processed), testutil.ToFloat64(processed)-before)
+
+			var blocks []uint64
+			for _, event := range eventsOf[TxEvent](env.recorder) {
+				blocks = append(blocks, event.BlockNumber)
+			}
+			assert.Equal(t, tt.blocks, blocks)
+
+			checkpoint, err := storage.ReadLastProcessedBlock(checkpointFile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.lastCheckpoint, checkpoint)
+		})
+	}
+}
+
+func TestScanner_BackfillOverridesLifecycle(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.LifecycleEvents = true
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	env.chain.AppendBlock(env.transferToUser(t, 1))
+
+	require.NoError(t, env.scanner.Backfill(1, 1, filepath.Join(t.TempDir(), "backfill.txt")))
+
+	// Backfilled blocks are published once with the finality policy's state
+	events := eventsOf[TxEvent](env.recorder)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventStateConfirmed, events[0].State)
+	assert.False(t, env.scanner.lifecycle)
+}
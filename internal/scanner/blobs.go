@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// applyBlobReceipt adds the blobs of an EIP-4844 transaction and the blob fee
+// paid for them to an event. The blob fee is burnt on top of the execution fee.
+func applyBlobReceipt(event *TxEvent, tx *types.Transaction, receipt *types.Receipt) {
+	if tx.Type() != types.BlobTxType {
+		return
+	}
+
+	hashes := tx.BlobHashes()
+	event.BlobCount = len(hashes)
+	event.BlobVersionedHashes = make([]string, len(hashes))
+	for i, hash := range hashes {
+		event.BlobVersionedHashes[i] = hash.Hex()
+	}
+
+	event.BlobGasUsed = receipt.BlobGasUsed
+	if receipt.BlobGasPrice != nil {
+		fee := new(big.Int).Mul(receipt.BlobGasPrice, new(big.Int).SetUint64(receipt.BlobGasUsed))
+		event.BlobGasPrice = receipt.BlobGasPrice.String()
+		event.BlobFeeWei = fee.String()
+		event.BlobFeeEth = WeiToEther(fee)
+	}
+}
+
+// parseBlobInboxes parses userId:address entries of inbox addresses watched
+// for blob transactions
+func parseBlobInboxes(entries []string) (map[string]string, error) {
+	inboxes := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		userID, address, ok := strings.Cut(entry, ":")
+		if !ok || userID == "" || !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid blob inbox %q, expected userId:address", entry)
+		}
+		inboxes[strings.ToLower(address)] = userID
+	}
+	return inboxes, nil
+}
+
+// blobInboxUserID returns the user watching the inbox a blob transaction is sent to
+func (s *Scanner) blobInboxUserID(tx *types.Transaction, to string) (string, bool) {
+	if tx.Type() != types.BlobTxType {
+		return "", false
+	}
+	userID, ok := s.blobInboxes[to]
+	return userID, ok
+}
+
+// blobInboxCandidates returns the watched inboxes that blob transactions of a
+// block are sent to, which are matched even though they are not monitored addresses
+func (s *Scanner) blobInboxCandidates(block *types.Block) []string {
+	if len(s.blobInboxes) == 0 {
+		return nil
+	}
+
+	var inboxes []string
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil {
+			continue
+		}
+		to := strings.ToLower(tx.To().Hex())
+		if _, ok := s.blobInboxUserID(tx, to); ok {
+			inboxes = append(inboxes, to)
+		}
+	}
+	return inboxes
+}
@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_WatchesBlobInboxes(t *testing.T) {
+	inbox := common.HexToAddress("0xff00000000000000000000000000000000000010")
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.BlobInboxes = []string{"rollup:" + inbox.Hex()}
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	hashes := []common.Hash{
+		common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000001"),
+		common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000002"),
+	}
+	blobTx := env.signTx(t, &types.BlobTx{
+		Gas:        21000,
+		To:         inbox,
+		BlobFeeCap: uint256.NewInt(3),
+		BlobHashes: hashes,
+	})
+	// Plain transactions to the inbox are not matched
+	calldataTx := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &inbox})
+	env.chain.AppendBlock(calldataTx, blobTx)
+
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	require.Len(t, events, 1)
+	assert.Equal(t, "rollup", events[0].UserID)
+	assert.Equal(t, DirectionIncoming, events[0].Direction)
+	assert.Equal(t, blobTx.Hash().Hex(), events[0].Hash)
+	assert.Equal(t, uint8(types.BlobTxType), events[0].TxType)
+	assert.Equal(t, 2, events[0].BlobCount)
+	assert.Equal(t, []string{hashes[0].Hex(), hashes[1].Hex()}, events[0].BlobVersionedHashes)
+	assert.Equal(t, uint64(2*params.BlobTxBlobGasPerBlob), events[0].BlobGasUsed)
+	assert.Equal(t, "3", events[0].BlobGasPrice)
+	assert.Equal(t, "786432", events[0].BlobFeeWei)
+}
+
+func TestScanner_ParseBlobInboxes(t *testing.T) {
+	inboxes, err := parseBlobInboxes([]string{"rollup:0xFF00000000000000000000000000000000000010"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0xff00000000000000000000000000000000000010": "rollup"}, inboxes)
+
+	_, err = parseBlobInboxes([]string{"0xff00000000000000000000000000000000000010"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// processContractCreation returns an event for a contract deployed by a monitored
+// sender. The contract address is derived from the sender and nonce and replaced
+// by the receipt's address once the receipt is applied.
+func (s *Scanner) processContractCreation(tx *types.Transaction, block *types.Block, from string, candidates map[string]bool) []TxEvent {
+	userID, ok := s.userID(from)
+	if !ok || !candidates[from] {
+		return nil
+	}
+
+	contract := strings.ToLower(crypto.CreateAddress(common.HexToAddress(from), tx.Nonce()).Hex())
+	event := s.newTxEvent(userID, from, contract, tx, block)
+	event.Type = EventTypeContractCreation
+	event.Direction = DirectionOutgoing
+	event.ContractAddress = contract
+	return []TxEvent{event}
+}
+
+// watchDeployedContracts starts monitoring the contracts successfully deployed
+// by monitored users in the events of a block when auto-watching deployed
+// contracts is enabled
+func (s *Scanner) watchDeployedContracts(events []Event) {
+	if !s.watchDeployed {
+		return
+	}
+	for _, e := range events {
+		event, ok := e.(TxEvent)
+		if !ok || event.Type != EventTypeContractCreation || event.Status != TxStatusSuccess || event.ContractAddress == "" {
+			continue
+		}
+		s.watchAddress(event.ContractAddress, event.UserID)
+	}
+}
@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_ContractCreation(t *testing.T) {
+	tests := []struct {
+		name           string
+		watchDeployed  bool
+		wantTokenEvent bool
+	}{
+		{"deployed contracts are reported", false, false},
+		{"deployed contracts are watched", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.Confirmations = 0
+			cfg.WatchDeployedContracts = tt.watchDeployed
+			cfg.WatchedAddressesFile = filepath.Join(t.TempDir(), "watched-addresses.csv")
+			env := newTestEnv(t, fakechain.New(1), cfg)
+
+			deployer := crypto.PubkeyToAddress(env.key.PublicKey)
+			env.scanner.watchAddress(strings.ToLower(deployer.Hex()), "user2")
+			require.NoError(t, env.scanner.Start())
+
+			tx := env.signTx(t, &types.DynamicFeeTx{Gas: 200000, Value: big.NewInt(5), Data: []byte{0x60, 0x00}})
+			contract := crypto.CreateAddress(deployer, tx.Nonce())
+			env.chain.AppendBlock(tx)
+
+			events := waitFor[TxEvent](t, env.recorder, 1)
+			assert.Equal(t, EventTypeContractCreation, events[0].Type)
+			assert.Equal(t, "user2", events[0].UserID)
+			assert.Equal(t, strings.ToLower(contract.Hex()), events[0].ContractAddress)
+			assert.Equal(t, events[0].ContractAddress, events[0].To)
+			assert.Equal(t, "5", events[0].AmountWei)
+			assert.Equal(t, TxStatusSuccess, events[0].Status)
+
+			// Tokens sent to the contract are only seen when it is watched
+			other := common.HexToAddress("0x6666666666666666666666666666666666666666")
+			call := env.transferToUser(t, 0)
+			env.chain.AddLogs(call.Hash(), erc20Transfer(testToken, other, contract, 9))
+			env.chain.AppendBlock(call)
+			waitFor[TxEvent](t, env.recorder, 2)
+
+			tokens := eventsOf[TokenEvent](env.recorder)
+			addresses, err := storage.ReadAddresses(cfg.WatchedAddressesFile)
+			require.NoError(t, err)
+			if !tt.wantTokenEvent {
+				assert.Empty(t, tokens)
+				assert.NotContains(t, addresses, strings.ToLower(contract.Hex()))
+				return
+			}
+			require.Eventually(t, func() bool {
+				return len(eventsOf[TokenEvent](env.recorder)) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			assert.Equal(t, "user2", eventsOf[TokenEvent](env.recorder)[0].UserID)
+			assert.Equal(t, "user2", addresses[strings.ToLower(contract.Hex())])
+		})
+	}
+}
+
+func TestScanner_WatchesDeployedContractsDuringCatchUp(t *testing.T) {
+	fake := fakechain.New(1)
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.FetchConcurrency = 4
+	cfg.WatchDeployedContracts = true
+	cfg.WatchedAddressesFile = filepath.Join(t.TempDir(), "watched-addresses.csv")
+	env := newTestEnv(t, fake, cfg)
+
+	deployer := crypto.PubkeyToAddress(env.key.PublicKey)
+	env.scanner.watchAddress(strings.ToLower(deployer.Hex()), "user2")
+
+	// The receipt of the deployment is slow, so the following blocks are
+	// matched before the deployment is published
+	fake.SetReceiptLatency(50 * time.Millisecond)
+
+	// Tokens are sent to the contract by an unmonitored sender in the blocks
+	// right after its deployment, mined while the scanner was down
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	stranger := &testEnv{key: key}
+	other := common.HexToAddress("0x6666666666666666666666666666666666666666")
+
+	deploy := env.signTx(t, &types.DynamicFeeTx{Gas: 200000, Data: []byte{0x60, 0x00}})
+	contract := crypto.CreateAddress(deployer, deploy.Nonce())
+	fake.AppendBlock()
+	fake.AppendBlock(deploy)
+	for i := int64(1); i <= 3; i++ {
+		call := stranger.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken})
+		fake.AddLogs(call.Hash(), erc20Transfer(testToken, other, contract, i))
+		fake.AppendBlock(call)
+	}
+
+	require.NoError(t, storage.WriteLastProcessedBlock(cfg.CheckpointFile, 1))
+	env.scanner.lastBlock = 1
+	require.NoError(t, env.scanner.Start())
+
+	tokens := waitFor[TokenEvent](t, env.recorder, 3)
+	for i, event := range tokens {
+		assert.Equal(t, "user2", event.UserID)
+		assert.Equal(t, strings.ToLower(contract.Hex()), event.To)
+		assert.Equal(t, uint64(i+3), event.BlockNumber)
+	}
+}
+
+func TestScanner_SavesWatchedAddressesToOwnFile(t *testing.T) {
+	dir := t.TempDir()
+	cfg := newTestConfig(t)
+	cfg.AddressesFilePath = filepath.Join(dir, "addresses.csv")
+	cfg.WatchedAddressesFile = filepath.Join(dir, "watched-addresses.csv")
+	env := newTestEnv(t, fakechain.New(1), cfg)
+
+	contract := "0x5555555555555555555555555555555555555555"
+	env.scanner.watchAddress(contract, "user2")
+
+	watched, err := storage.ReadAddresses(cfg.WatchedAddressesFile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{contract: "user2"}, watched)
+	// The address list is never rewritten
+	assert.NoFileExists(t, cfg.AddressesFilePath)
+}
@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"go.uber.org/multierr"
+)
+
+// Actions of an EIP-7702 authorization on the code of its authority
+const (
+	DelegationActionDelegated = "delegated"
+	DelegationActionCleared   = "cleared"
+)
+
+// SeverityHigh marks events that may indicate a compromised account
+const SeverityHigh = "high"
+
+// DelegationEvent represents an EIP-7702 authorization that delegates the code
+// of a monitored address to a contract, or clears an existing delegation.
+// Authorizations are signed by the authority but may be submitted by anyone,
+// so the sender of the set-code transaction is usually not the monitored user.
+type DelegationEvent struct {
+	BaseEvent
+	Severity    string `json:"severity"`
+	Action      string `json:"action"`
+	Authority   string `json:"authority"`
+	Delegate    string `json:"delegate,omitempty"`
+	AuthChainID string `json:"authChainId"`
+	AuthNonce   uint64 `json:"authNonce"`
+	Sender      string `json:"sender"`
+	Hash        string `json:"hash"`
+}
+
+// validate checks that all the required fields of a delegation event are present
+func (e DelegationEvent) validate() error {
+	var validatorErr error
+	if e.UserID == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("userId is required"))
+	}
+	if e.Authority == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("authority is required"))
+	}
+	if e.Hash == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("hash is required"))
+	}
+	if e.BlockNumber == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("blockNumber is required"))
+	}
+	return validatorErr
+}
+
+func (e DelegationEvent) withState(state string) Event { e.State = state; return e }
+
+// matchDelegations recovers the authority of every authorization in the
+// set-code transactions of a block and returns an event for each one signed by
+// a monitored address. Authorizations are applied before execution and are not
+// undone when the transaction reverts, so the receipt status is not checked.
+// Authorizations for another chain are ignored as the node rejects them.
+func (s *Scanner) matchDelegations(block *types.Block) []Event {
+	var events []Event
+	for _, tx := range block.Transactions() {
+		if tx.Type() != types.SetCodeTxType {
+			continue
+		}
+		sender, err := GetSenderAddress(tx)
+		if err != nil {
+			continue
+		}
+
+		for _, auth := range tx.SetCodeAuthorizations() {
+			if !auth.ChainID.IsZero() && auth.ChainID.Uint64() != s.chainID {
+				continue
+			}
+			authority, err := auth.Authority()
+			if err != nil {
+				continue
+			}
+			address := strings.ToLower(authority.Hex())
+			userID, ok := s.monitored(address)
+			if !ok {
+				continue
+			}
+
+			event := DelegationEvent{
+				BaseEvent:   s.newBaseEvent(EventTypeDelegation, userID, block),
+				Severity:    SeverityHigh,
+				Action:      DelegationActionCleared,
+				Authority:   address,
+				AuthChainID: auth.ChainID.Dec(),
+				AuthNonce:   auth.Nonce,
+				Sender:      strings.ToLower(sender),
+				Hash:        tx.Hash().Hex(),
+			}
+			// Delegating to the zero address resets the code of the authority
+			if auth.Address != (common.Address{}) {
+				event.Action = DelegationActionDelegated
+				event.Delegate = strings.ToLower(auth.Address.Hex())
+			}
+
+			s.logger.Warnw("Code delegation detected for monitored address",
+				"user", userID,
+				"authority", address,
+				"action", event.Action,
+				"delegate", event.Delegate,
+				"hash", event.Hash,
+			)
+			events = append(events, event)
+			metrics.DelegationsDetected.WithLabelValues(s.chainName).Inc()
+		}
+	}
+	return events
+}
@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signAuth(t *testing.T, chainID uint64, delegate common.Address, nonce uint64) (types.SetCodeAuthorization, common.Address) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth, err := types.SignSetCode(key, types.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(chainID),
+		Address: delegate,
+		Nonce:   nonce,
+	})
+	require.NoError(t, err)
+	return auth, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestScanner_DetectsCodeDelegations(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+
+	drainer := common.HexToAddress("0xdddddddddddddddddddddddddddddddddddddddd")
+	delegated, victim := signAuth(t, 0, drainer, 3)
+	cleared, owner := signAuth(t, 1, common.Address{}, 7)
+	otherChain, user := signAuth(t, 8453, drainer, 0)
+	unmonitored, _ := signAuth(t, 1, drainer, 0)
+	env.scanner.watchAddress(strings.ToLower(victim.Hex()), "victim")
+	env.scanner.watchAddress(strings.ToLower(owner.Hex()), "owner")
+	env.scanner.watchAddress(strings.ToLower(user.Hex()), "user")
+	require.NoError(t, env.scanner.Start())
+
+	tx := env.signTx(t, &types.SetCodeTx{
+		Gas:      100000,
+		To:       common.HexToAddress("0x9999999999999999999999999999999999999999"),
+		AuthList: []types.SetCodeAuthorization{delegated, cleared, otherChain, unmonitored},
+	})
+	block := env.chain.AppendBlock(tx)
+
+	events := waitFor[DelegationEvent](t, env.recorder, 2)
+	require.Len(t, events, 2)
+
+	sender := strings.ToLower(crypto.PubkeyToAddress(env.key.PublicKey).Hex())
+	assert.Equal(t, EventTypeDelegation, events[0].Type)
+	assert.Equal(t, SeverityHigh, events[0].Severity)
+	assert.Equal(t, "victim", events[0].UserID)
+	assert.Equal(t, DelegationActionDelegated, events[0].Action)
+	assert.Equal(t, strings.ToLower(victim.Hex()), events[0].Authority)
+	assert.Equal(t, strings.ToLower(drainer.Hex()), events[0].Delegate)
+	assert.Equal(t, "0", events[0].AuthChainID)
+	assert.Equal(t, uint64(3), events[0].AuthNonce)
+	assert.Equal(t, sender, events[0].Sender)
+	assert.Equal(t, tx.Hash().Hex(), events[0].Hash)
+	assert.Equal(t, block.Hash().Hex(), events[0].BlockHash)
+
+	assert.Equal(t, "owner", events[1].UserID)
+	assert.Equal(t, DelegationActionCleared, events[1].Action)
+	assert.Empty(t, events[1].Delegate)
+	assert.Equal(t, "1", events[1].AuthChainID)
+}
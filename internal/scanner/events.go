@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Event types telling consumers which kind of event they received
+const (
+	EventTypeTransaction      = "transaction"
+	EventTypeContractCreation = "contract_creation"
+	EventTypeTokenTransfer    = "erc20_transfer"
+	EventTypeERC721Transfer   = "erc721_transfer"
+	EventTypeERC1155Transfer  = "erc1155_transfer"
+	EventTypeInternal         = "internal_transfer"
+	EventTypePending          = "pending_transaction"
+	EventTypeWithdrawal       = "withdrawal"
+	EventTypeBlockReward      = "block_reward"
+	EventTypeDelegation       = "code_delegation"
+)
+
+// Directions of a transfer seen from the monitored address
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+	DirectionSelf     = "self"
+)
+
+// Event is implemented by every event the scanner publishes so that events of
+// different types are published, tracked and retracted alike
+type Event interface {
+	eventBlock() uint64
+	validate() error
+	// withState returns a copy of the event with its state set, so the same
+	// event can be published again at every stage of its lifecycle
+	withState(state string) Event
+}
+
+// BaseEvent holds the fields shared by the events found in a block. Event
+// types embed it and only add their own fields and validate.
+type BaseEvent struct {
+	Type        string `json:"type"`
+	ChainID     uint64 `json:"chainId"`
+	UserID      string `json:"userId"`
+	BlockNumber uint64 `json:"blockNumber"`
+	Timestamp   string `json:"timestamp"`
+	BlockHash   string `json:"blockHash"`
+	State       string `json:"state"`
+}
+
+func (e BaseEvent) eventBlock() uint64 { return e.BlockNumber }
+
+// newBaseEvent constructs the shared fields of an event of a block for a user
+func (s *Scanner) newBaseEvent(eventType, userID string, block *types.Block) BaseEvent {
+	return BaseEvent{
+		Type:        eventType,
+		ChainID:     s.chainID,
+		UserID:      userID,
+		BlockNumber: block.NumberU64(),
+		Timestamp:   time.Unix(int64(block.Time()), 0).Format(time.RFC3339),
+		BlockHash:   block.Hash().Hex(),
+	}
+}
+
+func (e TxEvent) validate() error { return ValidateEvent(e) }
+
+func (e TxEvent) withState(state string) Event { e.State = state; return e }
+
+// direction returns the direction of a transfer for the monitored address
+func direction(monitored, from, to string) string {
+	switch {
+	case from == to:
+		return DirectionSelf
+	case monitored == from:
+		return DirectionOutgoing
+	default:
+		return DirectionIncoming
+	}
+}
@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Finality policies deciding when a block is final enough to be processed
+const (
+	FinalityDepth     = "depth"     // A fixed number of confirmations below the head
+	FinalitySafe      = "safe"      // The node's "safe" block tag
+	FinalityFinalized = "finalized" // The node's "finalized" block tag
+)
+
+// finalityState returns the event state published for blocks processed under a policy
+func finalityState(policy string) (string, error) {
+	switch strings.ToLower(policy) {
+	case FinalityDepth:
+		return EventStateConfirmed, nil
+	case FinalitySafe:
+		return EventStateSafe, nil
+	case FinalityFinalized:
+		return EventStateFinalized, nil
+	default:
+		return "", fmt.Errorf("unknown finality policy %q, expected %s, %s or %s",
+			policy, FinalityDepth, FinalitySafe, FinalityFinalized)
+	}
+}
+
+// targetBlock returns the highest block that is final under the finality policy,
+// or the head in lifecycle mode where blocks are processed as soon as they are included
+func (s *Scanner) targetBlock() (uint64, error) {
+	if s.lifecycle {
+		head, err := s.client.BlockNumber(s.ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get head block: %v", err)
+		}
+		return head, nil
+	}
+
+	switch s.finality {
+	case FinalitySafe:
+		return s.taggedBlock(rpc.SafeBlockNumber)
+	case FinalityFinalized:
+		return s.taggedBlock(rpc.FinalizedBlockNumber)
+	}
+
+	head, err := s.client.BlockNumber(s.ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get head block: %v", err)
+	}
+	return s.depthTarget(head), nil
+}
+
+// targetForHeader returns the highest final block after a new head, avoiding
+// an RPC call for the depth policy where the target follows from the header
+func (s *Scanner) targetForHeader(header *types.Header) (uint64, error) {
+	if s.lifecycle {
+		return header.Number.Uint64(), nil
+	}
+	if s.finality == FinalityDepth {
+		return s.depthTarget(header.Number.Uint64()), nil
+	}
+	return s.targetBlock()
+}
+
+// depthTarget returns the block with enough confirmations below head, or 0
+func (s *Scanner) depthTarget(head uint64) uint64 {
+	if head < s.confirmations {
+		return 0
+	}
+	return head - s.confirmations
+}
+
+// taggedBlock returns the number of the block the node reports for a block tag
+func (s *Scanner) taggedBlock(tag rpc.BlockNumber) (uint64, error) {
+	header, err := s.client.HeaderByNumber(s.ctx, big.NewInt(tag.Int64()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s block: %v", tag, err)
+	}
+	return header.Number.Uint64(), nil
+}
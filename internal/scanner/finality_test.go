@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanner_FinalityState(t *testing.T) {
+	tests := []struct {
+		policy    string
+		expected  string
+		wantError bool
+	}{
+		{FinalityDepth, EventStateConfirmed, false},
+		{FinalitySafe, EventStateSafe, false},
+		{FinalityFinalized, EventStateFinalized, false},
+		{"FINALIZED", EventStateFinalized, false},
+		{"latest", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			state, err := finalityState(tt.policy)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, state)
+		})
+	}
+}
+
+func TestScanner_DepthTarget(t *testing.T) {
+	s := &Scanner{confirmations: 12}
+
+	assert.Equal(t, uint64(0), s.depthTarget(5))
+	assert.Equal(t, uint64(0), s.depthTarget(12))
+	assert.Equal(t, uint64(88), s.depthTarget(100))
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/abiregistry"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_DecodesTransactionInput(t *testing.T) {
+	selectors := filepath.Join(t.TempDir(), "selectors.csv")
+	require.NoError(t, os.WriteFile(selectors, []byte("0x095ea7b3,approve(address,uint256)\n"), 0644))
+
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.SelectorsFile = selectors
+	env := newTestEnv(t, fakechain.New(1), cfg)
+
+	sender := crypto.PubkeyToAddress(env.key.PublicKey)
+	env.scanner.watchAddress(strings.ToLower(sender.Hex()), "user2")
+	require.NoError(t, env.scanner.Start())
+
+	approve := hexutil.MustDecode("0x095ea7b3")
+	approve = append(approve, common.LeftPadBytes(env.user.Bytes(), 32)...)
+	approve = append(approve, common.BigToHash(big.NewInt(1000)).Bytes()...)
+	env.chain.AppendBlock(
+		env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken, Data: approve}),
+		env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken, Data: hexutil.MustDecode("0xdeadbeef")}),
+		env.transferToUser(t, 1),
+	)
+
+	events := waitFor[TxEvent](t, env.recorder, 4)
+	assert.Equal(t, "0x095ea7b3", events[0].Selector)
+	assert.Equal(t, "approve", events[0].Method)
+	assert.Equal(t, "approve(address,uint256)", events[0].MethodSignature)
+	assert.Equal(t, []abiregistry.Argument{
+		{Name: "arg0", Type: "address", Value: strings.ToLower(env.user.Hex())},
+		{Name: "arg1", Type: "uint256", Value: "1000"},
+	}, events[0].Args)
+
+	// Unknown selectors are published as the method
+	assert.Equal(t, "0xdeadbeef", events[1].Method)
+	assert.Empty(t, events[1].MethodSignature)
+	assert.Empty(t, events[1].Args)
+
+	// Plain sends carry no input
+	for _, event := range events[2:] {
+		assert.Empty(t, event.Selector)
+		assert.Empty(t, event.Method)
+	}
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+)
+
+// advanceLifecycle publishes the events of processed blocks again once they
+// reach the confirmation depth and once they are at or below the finalized tag
+func (s *Scanner) advanceLifecycle(head uint64) {
+	confirmed := min(s.depthTarget(head), s.lastBlock)
+	if confirmed > s.confirmedUpTo {
+		s.publishLifecycle(s.confirmedUpTo, confirmed, EventStateConfirmed, false)
+		s.confirmedUpTo = confirmed
+	}
+
+	finalized, err := s.taggedBlock(rpc.FinalizedBlockNumber)
+	if err != nil {
+		s.logger.Warnf("Failed to advance finalized events: %v", err)
+		return
+	}
+	finalized = min(finalized, s.lastBlock)
+	if finalized > s.finalizedUpTo {
+		s.publishLifecycle(s.finalizedUpTo, finalized, EventStateFinalized, true)
+		s.finalizedUpTo = finalized
+	}
+}
+
+// publishLifecycle publishes the events of the blocks (from, to] with state,
+// oldest block first. Final events are forgotten as they can no longer change.
+func (s *Scanner) publishLifecycle(from, to uint64, state string, final bool) {
+	var events []Event
+
+	s.publishedMu.Lock()
+	var blocks []uint64
+	for n := range s.published {
+		if n > from && n <= to {
+			blocks = append(blocks, n)
+		}
+	}
+	slices.Sort(blocks)
+	for _, n := range blocks {
+		events = append(events, s.published[n]...)
+		if final {
+			delete(s.published, n)
+		}
+	}
+	s.publishedMu.Unlock()
+
+	for _, event := range events {
+		event = event.withState(state)
+		s.logger.Infof("Event %s: %+v", state, event)
+		s.producer.PublishEvent(event)
+		metrics.KafkaEventsPublished.WithLabelValues(s.chainName).Inc()
+		metrics.LifecycleEvents.WithLabelValues(s.chainName, state).Inc()
+	}
+}
@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventStates(events []TxEvent) []string {
+	states := make([]string, len(events))
+	for i, event := range events {
+		states[i] = event.State
+	}
+	return states
+}
+
+func TestScanner_PublishesLifecycleEvents(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.LifecycleEvents = true
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	tx := env.transferToUser(t, 1)
+	env.chain.AppendBlock(tx)
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	assert.Equal(t, EventStateIncluded, events[0].State)
+
+	env.chain.AppendBlock()
+	env.chain.AppendBlock()
+	events = waitFor[TxEvent](t, env.recorder, 2)
+	assert.Equal(t, EventStateConfirmed, events[1].State)
+
+	env.chain.SetFinalized(1)
+	env.chain.AppendBlock()
+	events = waitFor[TxEvent](t, env.recorder, 3)
+	assert.Equal(t, []string{EventStateIncluded, EventStateConfirmed, EventStateFinalized}, eventStates(events))
+
+	for _, event := range events {
+		assert.Equal(t, tx.Hash().Hex(), event.Hash)
+		assert.Equal(t, "user1", event.UserID)
+	}
+}
+
+func TestScanner_LifecycleRetractsBeforeConfirmation(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.LifecycleEvents = true
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	tx := env.transferToUser(t, 1)
+	env.chain.AppendBlock(tx)
+	waitFor[TxEvent](t, env.recorder, 1)
+
+	// The transaction moves from block 1 to block 2 of a competing branch
+	env.chain.Fork(0)
+	env.chain.AppendBlock()
+	env.chain.AppendBlock(tx)
+	events := waitFor[TxEvent](t, env.recorder, 3)
+	assert.Equal(t, []string{EventStateIncluded, EventStateRetracted, EventStateIncluded}, eventStates(events))
+	assert.Equal(t, uint64(2), events[2].BlockNumber)
+
+	// Only the surviving inclusion is confirmed
+	env.chain.AppendBlock()
+	env.chain.AppendBlock()
+	events = waitFor[TxEvent](t, env.recorder, 4)
+	assert.Equal(t, EventStateConfirmed, events[3].State)
+	assert.Equal(t, uint64(2), events[3].BlockNumber)
+}
@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	kafka "github.com/nagdahimanshu/ethereum-block-scanner/internal/events"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+)
+
+// PendingEvent represents a pending transaction involving a monitored address.
+// It is published once when the transaction is first seen in the mempool and
+// again with state expired if it is not mined within the pending TTL.
+type PendingEvent struct {
+	Type               string `json:"type"`
+	ChainID            uint64 `json:"chainId"`
+	UserID             string `json:"userId"`
+	Direction          string `json:"direction"`
+	CounterpartyUserID string `json:"counterpartyUserId,omitempty"`
+	From               string `json:"from"`
+	To                 string `json:"to"`
+	AmountWei          string `json:"amountWei"`
+	AmountEth          string `json:"amountEth"`
+	Hash               string `json:"hash"`
+	Nonce              uint64 `json:"nonce"`
+	FirstSeen          string `json:"firstSeen"`
+	State              string `json:"state"`
+}
+
+// pendingEntry is a matched pending transaction tracked until it is mined or expires
+type pendingEntry struct {
+	events    []PendingEvent
+	firstSeen time.Time
+	mined     bool
+}
+
+// mempool deduplicates matched pending transactions and expires the ones that
+// are never mined
+type mempool struct {
+	mu       sync.Mutex
+	chain    string
+	ttl      time.Duration
+	entries  map[string]*pendingEntry
+	producer kafka.Publisher
+}
+
+func newMempool(chain string, ttl time.Duration, producer kafka.Publisher) *mempool {
+	return &mempool{
+		chain:    chain,
+		ttl:      ttl,
+		entries:  make(map[string]*pendingEntry),
+		producer: producer,
+	}
+}
+
+// track records the events of a pending transaction and reports whether it was
+// seen for the first time
+func (m *mempool) track(hash string, events []PendingEvent, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[hash]; ok {
+		return false
+	}
+	m.entries[hash] = &pendingEntry{events: events, firstSeen: now}
+	metrics.PendingTransactionsTracked.WithLabelValues(m.chain).Set(float64(len(m.entries)))
+	return true
+}
+
+// markMined stops a pending transaction from expiring. The entry is kept until
+// the TTL passes so late mempool announcements are still deduplicated.
+func (m *mempool) markMined(hash string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[hash]; ok {
+		entry.mined = true
+	}
+}
+
+// expire removes entries older than the TTL and returns the events of the
+// ones that were never mined
+func (m *mempool) expire(now time.Time) []PendingEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired []PendingEvent
+	for hash, entry := range m.entries {
+		if now.Sub(entry.firstSeen) < m.ttl {
+			continue
+		}
+		delete(m.entries, hash)
+		if !entry.mined {
+			expired = append(expired, entry.events...)
+		}
+	}
+	metrics.PendingTransactionsTracked.WithLabelValues(m.chain).Set(float64(len(m.entries)))
+	return expired
+}
+
+// WatchMempool subscribes to pending transactions and publishes pending events
+// for monitored addresses to producer until the scanner's context is done.
+// It must be called before Start.
+func (s *Scanner) WatchMempool(producer kafka.Publisher, ttl time.Duration) {
+	s.mempool = newMempool(s.chainName, ttl, producer)
+	go s.runMempool()
+}
+
+// runMempool keeps a pending transaction subscription on the active provider,
+// resubscribing after failures, and periodically expires stale entries
+func (s *Scanner) runMempool() {
+	sweep := time.NewTicker(min(s.mempool.ttl, time.Minute))
+	defer sweep.Stop()
+
+	for s.ctx.Err() == nil {
+		txs := make(chan *types.Transaction, 256)
+		provider := s.pool.Active()
+		sub, err := chain.SubscribePendingTransactions(s.ctx, provider.Client, txs)
+		if err != nil {
+			s.logger.Warnw("Failed to subscribe to pending transactions",
+				"provider", provider.Name,
+				"error", err,
+				"retry_in", s.retryDelay,
+			)
+			select {
+			case <-time.After(s.retryDelay):
+			case <-s.ctx.Done():
+			}
+			continue
+		}
+		s.logger.Infow("Watching mempool", "provider", provider.Name)
+
+	loop:
+		for {
+			select {
+			case <-s.ctx.Done():
+				sub.Unsubscribe()
+				return
+			case err := <-sub.Err():
+				s.logger.Warnw("Pending transaction subscription dropped", "provider", provider.Name, "error", err)
+				sub.Unsubscribe()
+				break loop
+			case tx := <-txs:
+				s.handlePending(tx, time.Now())
+			case now := <-sweep.C:
+				for _, event := range s.mempool.expire(now) {
+					event.State = EventStateExpired
+					s.logger.Infof("Pending transaction expired: %+v", event)
+					s.mempool.producer.PublishEvent(event)
+					metrics.PendingTransactionsExpired.WithLabelValues(s.chainName).Inc()
+				}
+			}
+		}
+	}
+}
+
+// handlePending matches a pending transaction against the monitored addresses
+// and publishes its events the first time it is seen
+func (s *Scanner) handlePending(tx *types.Transaction, now time.Time) {
+	from, err := GetSenderAddress(tx)
+	if err != nil {
+		return
+	}
+	var to string
+	if tx.To() != nil {
+		to = strings.ToLower(tx.To().Hex())
+	}
+
+	parties := s.monitoredParties(from, to)
+	if len(parties) == 0 {
+		return
+	}
+
+	events := make([]PendingEvent, 0, len(parties))
+	for _, party := range parties {
+		events = append(events, PendingEvent{
+			Type:               EventTypePending,
+			ChainID:            s.chainID,
+			UserID:             party.userID,
+			Direction:          direction(party.address, from, to),
+			CounterpartyUserID: counterpartyUserID(parties, party),
+			From:               from,
+			To:                 to,
+			AmountWei:          tx.Value().String(),
+			AmountEth:          WeiToEther(tx.Value()),
+			Hash:               tx.Hash().Hex(),
+			Nonce:              tx.Nonce(),
+			FirstSeen:          now.Format(time.RFC3339),
+			State:              EventStatePending,
+		})
+	}
+
+	if !s.mempool.track(tx.Hash().Hex(), events, now) {
+		return
+	}
+	for _, event := range events {
+		s.logger.Infof("Pending transaction detected: %+v", event)
+		s.mempool.producer.PublishEvent(event)
+		metrics.PendingTransactionsDetected.WithLabelValues(s.chainName).Inc()
+	}
+}
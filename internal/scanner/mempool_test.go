@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_MempoolExpiry(t *testing.T) {
+	m := newMempool("ethereum", time.Minute, &recorder{})
+	start := time.Unix(1_700_000_000, 0)
+
+	assert.True(t, m.track("0x1", []PendingEvent{{Hash: "0x1"}}, start))
+	assert.False(t, m.track("0x1", []PendingEvent{{Hash: "0x1"}}, start.Add(time.Second)))
+	assert.True(t, m.track("0x2", []PendingEvent{{Hash: "0x2"}}, start.Add(30*time.Second)))
+	m.markMined("0x1")
+
+	assert.Empty(t, m.expire(start.Add(59*time.Second)))
+
+	// Mined transactions leave silently, the others expire once the TTL passed
+	assert.Empty(t, m.expire(start.Add(time.Minute)))
+	assert.Equal(t, []PendingEvent{{Hash: "0x2"}}, m.expire(start.Add(90*time.Second)))
+	assert.Empty(t, m.entries)
+
+	// A nil mempool ignores mined transactions
+	var disabled *mempool
+	disabled.markMined("0x3")
+}
+
+func TestScanner_PublishesPendingTransactions(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.SkipFailedTxs = true
+	env := newTestEnv(t, fakechain.New(1), cfg)
+
+	pending := &recorder{}
+	env.scanner.WatchMempool(pending, 500*time.Millisecond)
+	require.NoError(t, env.scanner.Start())
+	require.Eventually(t, func() bool {
+		return env.chain.PendingSubscribers() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Announcements of the same transaction are deduplicated
+	mined := env.transferToUser(t, 1)
+	env.chain.AnnouncePending(mined)
+	env.chain.AnnouncePending(mined)
+	require.Eventually(t, func() bool {
+		return len(eventsOf[PendingEvent](pending)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	event := eventsOf[PendingEvent](pending)[0]
+	assert.Equal(t, EventTypePending, event.Type)
+	assert.Equal(t, EventStatePending, event.State)
+	assert.Equal(t, "user1", event.UserID)
+	assert.Equal(t, DirectionIncoming, event.Direction)
+	assert.Equal(t, mined.Hash().Hex(), event.Hash)
+
+	env.chain.AppendBlock(mined)
+	waitFor[TxEvent](t, env.recorder, 1)
+
+	// A reverted transaction skipped by SKIP_FAILED_TXS was mined all the same
+	reverted := env.transferToUser(t, 2)
+	env.chain.FailTransaction(reverted.Hash())
+	env.chain.AnnouncePending(reverted)
+	require.Eventually(t, func() bool {
+		return len(eventsOf[PendingEvent](pending)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	env.chain.AppendBlock(reverted)
+	require.Eventually(t, func() bool {
+		checkpoint, err := storage.ReadLastProcessedBlock(cfg.CheckpointFile)
+		return err == nil && checkpoint == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	dropped := env.transferToUser(t, 3)
+	env.chain.AnnouncePending(dropped)
+
+	require.Eventually(t, func() bool {
+		events := eventsOf[PendingEvent](pending)
+		return len(events) == 4 && events[3].State == EventStateExpired
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, dropped.Hash().Hex(), eventsOf[PendingEvent](pending)[3].Hash)
+
+	// Pending events never reach the block event publisher
+	assert.Empty(t, eventsOf[PendingEvent](env.recorder))
+}
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"go.uber.org/multierr"
+)
+
+// Selectors of the optional ERC-20 metadata methods
+var (
+	decimalsSelector = crypto.Keccak256([]byte("decimals()"))[:4]
+	symbolSelector   = crypto.Keccak256([]byte("symbol()"))[:4]
+	nameSelector     = crypto.Keccak256([]byte("name()"))[:4]
+)
+
+// revertedCode is the JSON-RPC error code of a call reverted by the contract
+const revertedCode = 3
+
+// stringArgs are the return values of symbol() and name() in the ERC-20 standard
+var stringArgs = abi.Arguments{{Type: mustNewType("string")}}
+
+// tokenMetadataCache keeps the metadata of every token seen, persisted to a
+// JSON file so tokens are only resolved once across restarts
+type tokenMetadataCache struct {
+	mu     sync.Mutex
+	file   string
+	tokens map[string]storage.TokenMetadata
+}
+
+func newTokenMetadataCache(file string) (*tokenMetadataCache, error) {
+	tokens := make(map[string]storage.TokenMetadata)
+	if file != "" {
+		var err error
+		if tokens, err = storage.ReadTokenMetadata(file); err != nil {
+			return nil, err
+		}
+	}
+	return &tokenMetadataCache{file: file, tokens: tokens}, nil
+}
+
+// tokenMetadata returns the metadata of a token, calling the contract the first
+// time the token is seen. Metadata is only cached once every call either
+// succeeded or reverted, so a transient node error is retried on the next transfer.
+func (s *Scanner) tokenMetadata(token string) storage.TokenMetadata {
+	cache := s.tokenCache
+	cache.mu.Lock()
+	metadata, ok := cache.tokens[token]
+	cache.mu.Unlock()
+	if ok {
+		return metadata
+	}
+
+	contract := common.HexToAddress(token)
+	decimals, decimalsErr := s.callToken(contract, decimalsSelector)
+	symbol, symbolErr := s.callToken(contract, symbolSelector)
+	name, nameErr := s.callToken(contract, nameSelector)
+	metadata = storage.TokenMetadata{
+		Decimals: decodeTokenDecimals(decimals),
+		Symbol:   decodeTokenString(symbol),
+		Name:     decodeTokenString(name),
+	}
+	if err := multierr.Combine(decimalsErr, symbolErr, nameErr); err != nil {
+		s.logger.Warnw("Failed to resolve token metadata", "token", token, "error", err)
+		return metadata
+	}
+	metrics.TokenMetadataResolved.WithLabelValues(s.chainName).Inc()
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.tokens[token] = metadata
+	if cache.file != "" {
+		if err := storage.WriteTokenMetadata(cache.file, cache.tokens); err != nil {
+			s.logger.Errorf("Failed to write token metadata: %v", err)
+		}
+	}
+	return metadata
+}
+
+// callToken calls a metadata method of a token. A reverted call means the token
+// does not implement the method and returns no data rather than an error.
+func (s *Scanner) callToken(contract common.Address, selector []byte) ([]byte, error) {
+	data, err := s.client.CallContract(s.ctx, ethereum.CallMsg{To: &contract, Data: selector}, nil)
+	if isReverted(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// isReverted reports whether a call failed because the contract reverted, as
+// opposed to the node failing to execute it (rate limits, timeouts, missing state)
+func isReverted(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertedCode {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// decodeTokenDecimals decodes the uint8 returned by decimals(). Tokens
+// declaring it as uint256 return the same encoding.
+func decodeTokenDecimals(data []byte) *uint8 {
+	if len(data) < 32 {
+		return nil
+	}
+	value := new(big.Int).SetBytes(data[:32])
+	if !value.IsUint64() || value.Uint64() > 255 {
+		return nil
+	}
+	decimals := uint8(value.Uint64())
+	return &decimals
+}
+
+// decodeTokenString decodes the string returned by symbol() or name(). Early
+// tokens such as MKR return a zero padded bytes32 instead.
+func decodeTokenString(data []byte) string {
+	var value string
+	switch {
+	case len(data) == 32:
+		value = string(bytes.TrimRight(data, "\x00"))
+	case len(data) >= 64:
+		values, err := stringArgs.Unpack(data)
+		if err != nil {
+			return ""
+		}
+		value = values[0].(string)
+	}
+	if !utf8.ValidString(value) {
+		return ""
+	}
+	return strings.TrimSpace(strings.Trim(value, "\x00"))
+}
+
+// FormatUnits converts a raw token amount to a decimal string using the token's decimals
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	value := new(big.Float).SetInt(amount)
+	value.Quo(value, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	return value.Text('f', 8)
+}
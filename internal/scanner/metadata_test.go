@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimited is the JSON-RPC error of a provider throttling requests
+type rateLimited struct{}
+
+func (rateLimited) Error() string  { return "request rate exceeded" }
+func (rateLimited) ErrorCode() int { return -32005 }
+
+// abiString returns the ABI encoding of a single string return value
+func abiString(t *testing.T, value string) []byte {
+	data, err := stringArgs.Pack(value)
+	require.NoError(t, err)
+	return data
+}
+
+func TestScanner_DecodeTokenMetadata(t *testing.T) {
+	six := uint8(6)
+	assert.Equal(t, &six, decodeTokenDecimals(common.BigToHash(big.NewInt(6)).Bytes()))
+	assert.Nil(t, decodeTokenDecimals(common.BigToHash(big.NewInt(256)).Bytes()))
+	assert.Nil(t, decodeTokenDecimals(nil))
+
+	assert.Equal(t, "USDC", decodeTokenString(abiString(t, "USDC")))
+	assert.Equal(t, "MKR", decodeTokenString(common.RightPadBytes([]byte("MKR"), 32)))
+	assert.Equal(t, "", decodeTokenString(common.RightPadBytes([]byte{0xff, 0xfe}, 32)))
+	assert.Equal(t, "", decodeTokenString(nil))
+}
+
+func TestScanner_FormatUnits(t *testing.T) {
+	assert.Equal(t, "1234.56780000", FormatUnits(big.NewInt(1_234_567_800), 6))
+	assert.Equal(t, "42.00000000", FormatUnits(big.NewInt(42), 0))
+}
+
+func TestScanner_ResolvesTokenMetadata(t *testing.T) {
+	mkr := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+	bare := common.HexToAddress("0x4444444444444444444444444444444444444444")
+
+	fake := fakechain.New(1)
+	fake.SetCall(testToken, decimalsSelector, common.BigToHash(big.NewInt(6)).Bytes())
+	fake.SetCall(testToken, symbolSelector, abiString(t, "USDC"))
+	fake.SetCall(testToken, nameSelector, abiString(t, "USD Coin"))
+	fake.SetCall(mkr, decimalsSelector, common.BigToHash(big.NewInt(18)).Bytes())
+	fake.SetCall(mkr, symbolSelector, common.RightPadBytes([]byte("MKR"), 32))
+	fake.SetCall(mkr, nameSelector, common.RightPadBytes([]byte("Maker"), 32))
+
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.TokenMetadataFile = filepath.Join(t.TempDir(), "token-metadata.json")
+	env := newTestEnv(t, fake, cfg)
+	require.NoError(t, env.scanner.Start())
+
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	tx := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken})
+	env.chain.AddLogs(tx.Hash(),
+		erc20Transfer(testToken, other, env.user, 1_500_000),
+		erc20Transfer(mkr, other, env.user, 2e18),
+		erc20Transfer(bare, other, env.user, 7),
+		erc20Transfer(testToken, other, env.user, 1),
+	)
+	env.chain.AppendBlock(tx)
+
+	events := waitFor[TokenEvent](t, env.recorder, 4)
+
+	assert.Equal(t, "USDC", events[0].Symbol)
+	assert.Equal(t, "USD Coin", events[0].Name)
+	require.NotNil(t, events[0].Decimals)
+	assert.Equal(t, uint8(6), *events[0].Decimals)
+	assert.Equal(t, "1.50000000", events[0].AmountFormatted)
+
+	assert.Equal(t, "MKR", events[1].Symbol)
+	assert.Equal(t, "Maker", events[1].Name)
+	assert.Equal(t, "2.00000000", events[1].AmountFormatted)
+
+	// Tokens without metadata methods keep the raw amount only
+	assert.Nil(t, events[2].Decimals)
+	assert.Empty(t, events[2].Symbol)
+	assert.Empty(t, events[2].AmountFormatted)
+	assert.Equal(t, "7", events[2].Amount)
+
+	// Every token is resolved once
+	assert.Equal(t, "0.00000100", events[3].AmountFormatted)
+	assert.Equal(t, 9, env.chain.CallCount())
+
+	cached, err := storage.ReadTokenMetadata(cfg.TokenMetadataFile)
+	require.NoError(t, err)
+	assert.Len(t, cached, 3)
+	assert.Equal(t, "MKR", cached["0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2"].Symbol)
+}
+
+func TestScanner_RetriesTokenMetadataAfterNodeErrors(t *testing.T) {
+	fake := fakechain.New(1)
+	fake.SetCall(testToken, decimalsSelector, common.BigToHash(big.NewInt(6)).Bytes())
+	fake.SetCall(testToken, symbolSelector, abiString(t, "USDC"))
+	fake.SetCall(testToken, nameSelector, abiString(t, "USD Coin"))
+	fake.FailCalls(rateLimited{})
+
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.TokenMetadataFile = filepath.Join(t.TempDir(), "token-metadata.json")
+	env := newTestEnv(t, fake, cfg)
+	require.NoError(t, env.scanner.Start())
+
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	tx := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken})
+	env.chain.AddLogs(tx.Hash(), erc20Transfer(testToken, other, env.user, 1_500_000))
+	env.chain.AppendBlock(tx)
+
+	// A rate limited call is not a missing method, so nothing is cached
+	events := waitFor[TokenEvent](t, env.recorder, 1)
+	assert.Nil(t, events[0].Decimals)
+	assert.Empty(t, events[0].AmountFormatted)
+	assert.NoFileExists(t, cfg.TokenMetadataFile)
+
+	fake.FailCalls(nil)
+	tx = env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken})
+	env.chain.AddLogs(tx.Hash(), erc20Transfer(testToken, other, env.user, 2_000_000))
+	env.chain.AppendBlock(tx)
+
+	events = waitFor[TokenEvent](t, env.recorder, 2)
+	assert.Equal(t, "USDC", events[1].Symbol)
+	assert.Equal(t, "2.00000000", events[1].AmountFormatted)
+
+	cached, err := storage.ReadTokenMetadata(cfg.TokenMetadataFile)
+	require.NoError(t, err)
+	assert.Equal(t, "USDC", cached[strings.ToLower(testToken.Hex())].Symbol)
+}
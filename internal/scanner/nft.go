@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"go.uber.org/multierr"
+)
+
+// Topics of the ERC-1155 transfer events
+var (
+	TransferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	TransferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// transferBatchArgs are the non-indexed arguments of TransferBatch
+var transferBatchArgs = abi.Arguments{
+	{Type: mustNewType("uint256[]")},
+	{Type: mustNewType("uint256[]")},
+}
+
+// NFTEvent represents an ERC-721 or ERC-1155 transfer involving a monitored address
+type NFTEvent struct {
+	BaseEvent
+	Direction          string   `json:"direction"`
+	CounterpartyUserID string   `json:"counterpartyUserId,omitempty"`
+	Collection         string   `json:"collection"`
+	From               string   `json:"from"`
+	To                 string   `json:"to"`
+	Operator           string   `json:"operator,omitempty"`
+	TokenIDs           []string `json:"tokenIds"`
+	Quantities         []string `json:"quantities"`
+	LogIndex           uint     `json:"logIndex"`
+	Hash               string   `json:"hash"`
+}
+
+// validate checks that all the required fields of an NFT event are present
+func (e NFTEvent) validate() error {
+	var validatorErr error
+	if e.UserID == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("userId is required"))
+	}
+	if e.Collection == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("collection is required"))
+	}
+	if len(e.TokenIDs) == 0 || len(e.TokenIDs) != len(e.Quantities) {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("tokenIds and quantities must be non-empty and of equal length"))
+	}
+	if e.Hash == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("hash is required"))
+	}
+	if e.BlockNumber == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("blockNumber is required"))
+	}
+	return validatorErr
+}
+
+func (e NFTEvent) withState(state string) Event { e.State = state; return e }
+
+// nftTransfer is a decoded ERC-721 or ERC-1155 transfer log
+type nftTransfer struct {
+	eventType  string
+	operator   string
+	from       string
+	to         string
+	tokenIDs   []*big.Int
+	quantities []*big.Int
+}
+
+// matchNFTTransfers returns an event for every monitored party of the
+// ERC-721 and ERC-1155 transfer logs of a block
+func (s *Scanner) matchNFTTransfers(block *types.Block, logs []types.Log) []Event {
+	var events []Event
+	for _, log := range logs {
+		transfer, ok := decodeNFTTransfer(log)
+		if !ok {
+			continue
+		}
+
+		parties := s.monitoredParties(transfer.from, transfer.to)
+		for _, party := range parties {
+			events = append(events, NFTEvent{
+				BaseEvent:          s.newBaseEvent(transfer.eventType, party.userID, block),
+				Direction:          direction(party.address, transfer.from, transfer.to),
+				CounterpartyUserID: counterpartyUserID(parties, party),
+				Collection:         strings.ToLower(log.Address.Hex()),
+				From:               transfer.from,
+				To:                 transfer.to,
+				Operator:           transfer.operator,
+				TokenIDs:           bigsToStrings(transfer.tokenIDs),
+				Quantities:         bigsToStrings(transfer.quantities),
+				LogIndex:           log.Index,
+				Hash:               log.TxHash.Hex(),
+			})
+			metrics.NFTTransfersDetected.WithLabelValues(s.chainName).Inc()
+		}
+	}
+	return events
+}
+
+// decodeNFTTransfer decodes ERC-721 Transfer and ERC-1155 TransferSingle and
+// TransferBatch logs
+func decodeNFTTransfer(log types.Log) (nftTransfer, bool) {
+	if log.Removed || len(log.Topics) != 4 {
+		return nftTransfer{}, false
+	}
+
+	switch log.Topics[0] {
+	case TransferTopic:
+		if len(log.Data) != 0 {
+			return nftTransfer{}, false
+		}
+		return nftTransfer{
+			eventType:  EventTypeERC721Transfer,
+			from:       topicAddress(log.Topics[1]),
+			to:         topicAddress(log.Topics[2]),
+			tokenIDs:   []*big.Int{log.Topics[3].Big()},
+			quantities: []*big.Int{big.NewInt(1)},
+		}, true
+
+	case TransferSingleTopic:
+		if len(log.Data) != 64 {
+			return nftTransfer{}, false
+		}
+		return nftTransfer{
+			eventType:  EventTypeERC1155Transfer,
+			operator:   topicAddress(log.Topics[1]),
+			from:       topicAddress(log.Topics[2]),
+			to:         topicAddress(log.Topics[3]),
+			tokenIDs:   []*big.Int{new(big.Int).SetBytes(log.Data[:32])},
+			quantities: []*big.Int{new(big.Int).SetBytes(log.Data[32:])},
+		}, true
+
+	case TransferBatchTopic:
+		values, err := transferBatchArgs.Unpack(log.Data)
+		if err != nil {
+			return nftTransfer{}, false
+		}
+		ids, _ := values[0].([]*big.Int)
+		quantities, _ := values[1].([]*big.Int)
+		if len(ids) == 0 || len(ids) != len(quantities) {
+			return nftTransfer{}, false
+		}
+		return nftTransfer{
+			eventType:  EventTypeERC1155Transfer,
+			operator:   topicAddress(log.Topics[1]),
+			from:       topicAddress(log.Topics[2]),
+			to:         topicAddress(log.Topics[3]),
+			tokenIDs:   ids,
+			quantities: quantities,
+		}, true
+	}
+	return nftTransfer{}, false
+}
+
+// bigsToStrings formats big integers as decimal strings
+func bigsToStrings(values []*big.Int) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = v.String()
+	}
+	return out
+}
+
+// mustNewType returns the ABI type for t, panicking on invalid types
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCollection = common.HexToAddress("0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d")
+
+func addressTopic(addr common.Address) common.Hash {
+	return common.BytesToHash(addr.Bytes())
+}
+
+func erc721Transfer(collection, from, to common.Address, tokenID int64) *types.Log {
+	return &types.Log{
+		Address: collection,
+		Topics:  []common.Hash{TransferTopic, addressTopic(from), addressTopic(to), common.BigToHash(big.NewInt(tokenID))},
+	}
+}
+
+func erc1155Single(collection, operator, from, to common.Address, id, quantity int64) *types.Log {
+	data := append(common.BigToHash(big.NewInt(id)).Bytes(), common.BigToHash(big.NewInt(quantity)).Bytes()...)
+	return &types.Log{
+		Address: collection,
+		Topics:  []common.Hash{TransferSingleTopic, addressTopic(operator), addressTopic(from), addressTopic(to)},
+		Data:    data,
+	}
+}
+
+func erc1155Batch(t *testing.T, collection, operator, from, to common.Address, ids, quantities []*big.Int) *types.Log {
+	data, err := transferBatchArgs.Pack(ids, quantities)
+	require.NoError(t, err)
+	return &types.Log{
+		Address: collection,
+		Topics:  []common.Hash{TransferBatchTopic, addressTopic(operator), addressTopic(from), addressTopic(to)},
+		Data:    data,
+	}
+}
+
+func TestScanner_DecodeNFTTransfer(t *testing.T) {
+	operator := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	from := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	to := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	truncated := erc1155Batch(t, testCollection, operator, from, to, []*big.Int{big.NewInt(1)}, []*big.Int{big.NewInt(2)})
+	truncated.Data = truncated.Data[:64]
+
+	tests := []struct {
+		name           string
+		log            *types.Log
+		wantOk         bool
+		wantType       string
+		wantOperator   string
+		wantTokenIDs   []*big.Int
+		wantQuantities []*big.Int
+	}{
+		{
+			name:           "erc721 transfer",
+			log:            erc721Transfer(testCollection, from, to, 42),
+			wantOk:         true,
+			wantType:       EventTypeERC721Transfer,
+			wantTokenIDs:   []*big.Int{big.NewInt(42)},
+			wantQuantities: []*big.Int{big.NewInt(1)},
+		},
+		{
+			name:           "erc1155 single transfer",
+			log:            erc1155Single(testCollection, operator, from, to, 7, 10),
+			wantOk:         true,
+			wantType:       EventTypeERC1155Transfer,
+			wantOperator:   strings.ToLower(operator.Hex()),
+			wantTokenIDs:   []*big.Int{big.NewInt(7)},
+			wantQuantities: []*big.Int{big.NewInt(10)},
+		},
+		{
+			name:           "erc1155 batch transfer",
+			log:            erc1155Batch(t, testCollection, operator, from, to, []*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(3), big.NewInt(4)}),
+			wantOk:         true,
+			wantType:       EventTypeERC1155Transfer,
+			wantOperator:   strings.ToLower(operator.Hex()),
+			wantTokenIDs:   []*big.Int{big.NewInt(1), big.NewInt(2)},
+			wantQuantities: []*big.Int{big.NewInt(3), big.NewInt(4)},
+		},
+		{name: "erc20 transfer", log: erc20Transfer(testToken, from, to, 5)},
+		{name: "truncated batch", log: truncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfer, ok := decodeNFTTransfer(*tt.log)
+			assert.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				return
+			}
+			assert.Equal(t, tt.wantType, transfer.eventType)
+			assert.Equal(t, tt.wantOperator, transfer.operator)
+			assert.Equal(t, strings.ToLower(from.Hex()), transfer.from)
+			assert.Equal(t, strings.ToLower(to.Hex()), transfer.to)
+			assert.Equal(t, tt.wantTokenIDs, transfer.tokenIDs)
+			assert.Equal(t, tt.wantQuantities, transfer.quantities)
+		})
+	}
+}
+
+func TestScanner_DetectsNFTTransfers(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	other := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	tx := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testCollection})
+	env.chain.AddLogs(tx.Hash(),
+		erc721Transfer(testCollection, other, env.user, 42),
+		erc721Transfer(testCollection, other, other, 43),
+		erc1155Batch(t, testCollection, other, env.user, other,
+			[]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(5), big.NewInt(6)}),
+	)
+	env.chain.AppendBlock(tx)
+
+	events := waitFor[NFTEvent](t, env.recorder, 2)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, EventTypeERC721Transfer, events[0].Type)
+	assert.Equal(t, DirectionIncoming, events[0].Direction)
+	assert.Equal(t, strings.ToLower(testCollection.Hex()), events[0].Collection)
+	assert.Equal(t, []string{"42"}, events[0].TokenIDs)
+	assert.Equal(t, []string{"1"}, events[0].Quantities)
+	assert.Empty(t, events[0].Operator)
+
+	assert.Equal(t, EventTypeERC1155Transfer, events[1].Type)
+	assert.Equal(t, DirectionOutgoing, events[1].Direction)
+	assert.Equal(t, strings.ToLower(other.Hex()), events[1].Operator)
+	assert.Equal(t, []string{"1", "2"}, events[1].TokenIDs)
+	assert.Equal(t, []string{"5", "6"}, events[1].Quantities)
+	assert.Equal(t, uint(2), events[1].LogIndex)
+
+	// ERC-721 transfers share the ERC-20 topic but must not be reported as tokens
+	assert.Empty(t, eventsOf[TokenEvent](env.recorder))
+}
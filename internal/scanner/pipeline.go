@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+)
+
+// blockResult is a fetched and matched block waiting to be published in order
+type blockResult struct {
+	number  uint64
+	block   *types.Block
+	events  []Event
+	version uint64 // Version of the watched addresses the block was matched with
+	err     error
+}
+
+// processRange fetches and matches the blocks [from, to] concurrently and then
+// publishes them strictly in block order, calling commit after each block.
+// It stops at the first block that fails so the checkpoint never skips a block.
+func (s *Scanner) processRange(from, to uint64, commit func(blockNumber uint64)) error {
+	if from > to {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	concurrency := s.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// slots bounds how far fetching can run ahead of in-order publishing
+	slots := make(chan struct{}, concurrency*2)
+	numbers := make(chan uint64)
+	results := make(chan blockResult)
+
+	go func() {
+		defer close(numbers)
+		for n := from; n <= to; n++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case numbers <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range numbers {
+				result := s.fetchAndMatch(n)
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[uint64]blockResult)
+	next := from
+	for result := range results {
+		pending[result.number] = result
+
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			ready = s.rematchStale(ready)
+			if ready.err != nil {
+				return ready.err
+			}
+
+			s.publishBlock(ready.block, ready.events)
+			commit(next)
+			<-slots
+
+			if next == to {
+				return nil
+			}
+			next++
+		}
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("block pipeline stopped before block %d", next)
+}
+
+// fetchAndMatch fetches a single block and matches its transactions
+func (s *Scanner) fetchAndMatch(blockNumber uint64) blockResult {
+	started := time.Now()
+	block, err := s.fetchBlock(blockNumber)
+	metrics.BlockFetchDuration.WithLabelValues(s.chainName).Observe(time.Since(started).Seconds())
+	if err != nil {
+		return blockResult{
+			number: blockNumber,
+			err:    fmt.Errorf("failed to get block %d: %v", blockNumber, err),
+		}
+	}
+
+	if err := s.verifyQuorum(block); err != nil {
+		return blockResult{number: blockNumber, err: err}
+	}
+
+	version := s.addressVersion.Load()
+	events, err := s.matchBlock(block)
+	if err != nil {
+		return blockResult{number: blockNumber, err: err}
+	}
+
+	return blockResult{
+		number:  blockNumber,
+		block:   block,
+		events:  events,
+		version: version,
+	}
+}
+
+// rematchStale watches the contracts deployed in a block and matches the block
+// again while addresses were watched after it was matched. Blocks are matched
+// ahead of publishing, so this runs in block order to let a contract deployed
+// in one block match the transfers to it in the same and the following blocks.
+func (s *Scanner) rematchStale(result blockResult) blockResult {
+	for result.err == nil {
+		s.watchDeployedContracts(result.events)
+		version := s.addressVersion.Load()
+		if version == result.version {
+			return result
+		}
+		result.events, result.err = s.matchBlock(result.block)
+		result.version = version
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockNumbers returns the block numbers of the recorded transaction events
+func blockNumbers(r *recorder) []uint64 {
+	var numbers []uint64
+	for _, event := range eventsOf[TxEvent](r) {
+		numbers = append(numbers, event.BlockNumber)
+	}
+	return numbers
+}
+
+func TestScanner_ProcessRangePublishesInOrder(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.FetchConcurrency = 3
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	for range 6 {
+		env.chain.AppendBlock(env.transferToUser(t, 1))
+	}
+
+	// The first blocks are fetched last, the later ones wait for them
+	env.chain.SetBlockLatency(1, 200*time.Millisecond)
+	env.chain.SetBlockLatency(2, 100*time.Millisecond)
+
+	var committed []uint64
+	require.NoError(t, env.scanner.processRange(1, 6, func(blockNumber uint64) {
+		committed = append(committed, blockNumber)
+	}))
+
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, committed)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, blockNumbers(env.recorder))
+}
+
+func TestScanner_ProcessRangeStopsAtFailedBlock(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.FetchConcurrency = 3
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	for range 6 {
+		env.chain.AppendBlock(env.transferToUser(t, 1))
+	}
+
+	// Block 4 fails while the blocks before it are still being fetched
+	env.chain.SetBlockLatency(2, 100*time.Millisecond)
+	env.chain.FailBlock(4, errors.New("block unavailable"))
+
+	var committed []uint64
+	err := env.scanner.processRange(1, 6, func(blockNumber uint64) {
+		committed = append(committed, blockNumber)
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get block 4")
+
+	assert.Equal(t, []uint64{1, 2, 3}, committed, "the checkpoint should stop before the failed block")
+	assert.Equal(t, []uint64{1, 2, 3}, blockNumbers(env.recorder))
+
+	// The next run resumes at the failed block
+	env.chain.FailBlock(4, nil)
+	require.NoError(t, env.scanner.processRange(4, 6, func(blockNumber uint64) {
+		committed = append(committed, blockNumber)
+	}))
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, committed)
+}
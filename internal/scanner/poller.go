@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Head sources used to learn about new blocks
+const (
+	HeadSourceAuto      = "auto"      // Poll for HTTP(S) node URLs, subscribe otherwise
+	HeadSourceSubscribe = "subscribe" // Use eth_subscribe("newHeads")
+	HeadSourcePoll      = "poll"      // Poll eth_getBlockByNumber("latest") on an interval
+)
+
+// usePolling reports whether new heads should be polled instead of subscribed to
+func usePolling(headSource, nodeURL string) bool {
+	switch strings.ToLower(headSource) {
+	case HeadSourcePoll:
+		return true
+	case HeadSourceSubscribe:
+		return false
+	}
+
+	u, err := url.Parse(nodeURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// pollNewHeads emulates a newHeads subscription by polling the latest header.
+// Headers skipped between two polls are fetched too, so the confirmation and
+// reorg logic sees the same stream it would get from a subscription.
+func (s *Scanner) pollNewHeads(ch chan<- *types.Header) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+
+		var lastNumber uint64
+		var lastHash common.Hash
+		for {
+			head, err := s.client.HeaderByNumber(s.ctx, nil)
+			if err != nil {
+				return fmt.Errorf("failed to poll latest header: %v", err)
+			}
+
+			number := head.Number.Uint64()
+			if number != lastNumber || head.Hash() != lastHash {
+				headers := []*types.Header{head}
+				if lastNumber != 0 && number > lastNumber+1 {
+					headers, err = s.headersBetween(lastNumber+1, number-1)
+					if err != nil {
+						return err
+					}
+					headers = append(headers, head)
+				}
+
+				for _, header := range headers {
+					select {
+					case ch <- header:
+					case <-quit:
+						return nil
+					}
+				}
+				lastNumber, lastHash = number, head.Hash()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return nil
+			case <-s.ctx.Done():
+				return nil
+			}
+		}
+	})
+}
+
+// headersBetween fetches the headers of the inclusive range [from, to]
+func (s *Scanner) headersBetween(from, to uint64) ([]*types.Header, error) {
+	headers := make([]*types.Header, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		header, err := s.client.HeaderByNumber(s.ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get header %d: %v", n, err)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
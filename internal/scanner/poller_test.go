@@ -0,0 +1,30 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanner_UsePolling(t *testing.T) {
+	tests := []struct {
+		name       string
+		headSource string
+		nodeURL    string
+		expected   bool
+	}{
+		{"auto with https", HeadSourceAuto, "https://ethereum-rpc.publicnode.com", true},
+		{"auto with http", HeadSourceAuto, "http://localhost:8545", true},
+		{"auto with websocket", HeadSourceAuto, "wss://ethereum-rpc.publicnode.com", false},
+		{"auto with ipc path", HeadSourceAuto, "/tmp/geth.ipc", false},
+		{"forced poll", HeadSourcePoll, "wss://ethereum-rpc.publicnode.com", true},
+		{"forced subscribe", HeadSourceSubscribe, "http://localhost:8545", false},
+		{"empty source defaults to auto", "", "http://localhost:8545", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, usePolling(tt.headSource, tt.nodeURL))
+		})
+	}
+}
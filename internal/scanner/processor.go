@@ -4,23 +4,65 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
-	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/abiregistry"
 	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
 	"go.uber.org/multierr"
 )
 
+// Event states published with every TxEvent
+const (
+	EventStateIncluded  = "included"  // Transaction is part of the head block, published in lifecycle mode
+	EventStateConfirmed = "confirmed" // Transaction is part of a block with enough confirmations
+	EventStateSafe      = "safe"      // Transaction is part of a block at or below the safe tag
+	EventStateFinalized = "finalized" // Transaction is part of a block at or below the finalized tag
+	EventStateRetracted = "retracted" // Previously published transaction left the canonical chain
+	EventStatePending   = "pending"   // Transaction was seen in the mempool
+	EventStateExpired   = "expired"   // Pending transaction was not mined within the pending TTL
+)
+
 // TxEvent represents a normalized blockchain transaction event
 type TxEvent struct {
-	UserID      string `json:"userId"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	AmountWei   string `json:"amountWei"`
-	AmountEth   string `json:"amountEth"`
-	Hash        string `json:"hash"`
-	BlockNumber uint64 `json:"blockNumber"`
-	Timestamp   string `json:"timestamp"`
+	BaseEvent
+	Direction          string `json:"direction"`
+	CounterpartyUserID string `json:"counterpartyUserId,omitempty"`
+	From               string `json:"from"`
+	To                 string `json:"to"`
+	AmountWei          string `json:"amountWei"`
+	AmountEth          string `json:"amountEth"`
+	Hash               string `json:"hash"`
+
+	// USD value of the amount when a price source is configured
+	Valuation
+
+	// Execution outcome from the transaction receipt
+	Status            string `json:"status"`
+	TxType            uint8  `json:"txType"`
+	GasUsed           uint64 `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"`
+	FeeWei            string `json:"feeWei"`
+	FeeEth            string `json:"feeEth"`
+	ContractAddress   string `json:"contractAddress,omitempty"`
+
+	// Set on the MEV payment of a block, which is also published as a block_reward
+	// event, so consumers crediting both do not count the payment twice
+	MevPayment bool `json:"mevPayment,omitempty"`
+
+	// Decoded input of contract calls, the method is the raw selector when unknown
+	Selector        string                 `json:"selector,omitempty"`
+	Method          string                 `json:"method,omitempty"`
+	MethodSignature string                 `json:"methodSignature,omitempty"`
+	Args            []abiregistry.Argument `json:"args,omitempty"`
+
+	// Blob data of EIP-4844 transactions
+	BlobCount           int      `json:"blobCount,omitempty"`
+	BlobVersionedHashes []string `json:"blobVersionedHashes,omitempty"`
+	BlobGasUsed         uint64   `json:"blobGasUsed,omitempty"`
+	BlobGasPrice        string   `json:"blobGasPrice,omitempty"`
+	BlobFeeWei          string   `json:"blobFeeWei,omitempty"`
+	BlobFeeEth          string   `json:"blobFeeEth,omitempty"`
 }
 
 // ValidateEvent checks transaction event
@@ -55,24 +97,44 @@ func ValidateEvent(e TxEvent) error {
 	return validatorErr
 }
 
-// processNewBlock processes all transactions in a block using worker pool
-func (s *Scanner) processNewBlock(blockNumber uint64) {
-	metrics.CurrentBlock.Set(float64(blockNumber))
-	metrics.BlocksProcessed.Inc()
+// matchBlock returns the events of a block for the monitored addresses: the
+// receipt-enriched transactions, the internal ETH transfers found by call
+// tracing, the token and NFT transfers, the beacon chain withdrawals and the
+// fee recipient rewards
+func (s *Scanner) matchBlock(block *types.Block) ([]Event, error) {
+	events, err := s.matchTransactions(block)
+	if err != nil {
+		return nil, err
+	}
 
-	block, err := s.client.BlockByNumber(s.ctx, big.NewInt(int64(blockNumber)))
+	internal, err := s.matchInternalTransfers(block)
 	if err != nil {
-		s.logger.Infof("Error getting block %d: %v", blockNumber, err)
-		return
+		return nil, err
 	}
+	events = append(events, internal...)
 
-	s.logger.Infow("Processing block",
-		"block", blockNumber,
-		"hash", block.Hash().Hex(),
-	)
+	logs, err := s.fetchLogs(block, TransferTopic, TransferSingleTopic, TransferBatchTopic)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, s.matchTokenTransfers(block, logs)...)
+	events = append(events, s.matchNFTTransfers(block, logs)...)
+	events = append(events, s.matchWithdrawals(block)...)
+	events = append(events, s.matchDelegations(block)...)
 
-	metrics.TransactionsProcessed.Add(float64(len(block.Transactions())))
+	rewards, err := s.matchBlockRewards(block)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, rewards...)
+
+	s.valueEvents(block, events)
+	return events, nil
+}
 
+// matchTransactions tests every transaction of a block against the monitored
+// addresses and returns the receipt-enriched events for the matching ones
+func (s *Scanner) matchTransactions(block *types.Block) ([]Event, error) {
 	var addressesToCheck []string
 	for _, tx := range block.Transactions() {
 		from, err := GetSenderAddress(tx)
@@ -85,111 +147,174 @@ func (s *Scanner) processNewBlock(blockNumber uint64) {
 	}
 
 	potentialMatches := s.bloomFilter.BatchTest(addressesToCheck)
-
-	if len(potentialMatches) > 0 {
-		s.processTransactions(block, potentialMatches)
-	} else {
-		s.logger.Infof("No transactions detected from the list of addresses at block: %d", blockNumber)
+	potentialMatches = append(potentialMatches, s.blobInboxCandidates(block)...)
+	if len(potentialMatches) == 0 {
+		return nil, nil
 	}
-}
 
-// processTransactions uses a bounded worker pool to process transactions
-func (s *Scanner) processTransactions(block *types.Block, addresses []string) {
-	jobs := make(chan *types.Transaction, JobQueueSize)
-	done := make(chan bool)
-
-	// Start workers
-	for i := 0; i < NumWorkers; i++ {
-		go func() {
-			for tx := range jobs {
-				s.ProcessTransaction(tx, block, addresses)
-			}
-			done <- true
-		}()
+	candidates := make(map[string]bool, len(potentialMatches))
+	for _, addr := range potentialMatches {
+		candidates[addr] = true
 	}
 
-	// Add jobs into the queue
+	payment := mevPayment(block)
+	var events []Event
 	for _, tx := range block.Transactions() {
-		jobs <- tx
+		matched := s.ProcessTransaction(tx, block, candidates)
+		if len(matched) == 0 {
+			continue
+		}
+
+		receipt, err := s.fetchReceipt(tx)
+		if err != nil {
+			return nil, err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful && s.skipFailedTxs {
+			s.logger.Infof("Skipping failed transaction %s", tx.Hash().Hex())
+			metrics.FailedTransactionsSkipped.WithLabelValues(s.chainName).Inc()
+			// A reverted transaction was still mined, its pending entry must not expire
+			s.mempool.markMined(tx.Hash().Hex())
+			continue
+		}
+
+		for i := range matched {
+			applyReceipt(&matched[i], tx, receipt)
+			matched[i].MevPayment = tx == payment
+			events = append(events, matched[i])
+		}
 	}
-	close(jobs)
+	return events, nil
+}
 
-	// Wait for all workers to finish
-	for i := 0; i < NumWorkers; i++ {
-		<-done
+// fetchBlock fetches a block by its tracked canonical hash, falling back to its number
+func (s *Scanner) fetchBlock(blockNumber uint64) (*types.Block, error) {
+	if hash, ok := s.chain.hash(blockNumber); ok && hash != (common.Hash{}) {
+		return s.client.BlockByHash(s.ctx, hash)
 	}
+	return s.client.BlockByNumber(s.ctx, big.NewInt(int64(blockNumber)))
 }
 
 // ProcessTransaction processes a single transaction
 // Checks if sender or receiver is in monitored addresses
-// Logs and publishes events if a match is found
-func (s *Scanner) ProcessTransaction(tx *types.Transaction, block *types.Block, addresses []string) {
-	addressSet := make(map[string]bool)
-	for _, addr := range addresses {
-		addressSet[addr] = true
-	}
-
+// Returns one event per monitored party, so a transfer between two
+// monitored users notifies both of them
+func (s *Scanner) ProcessTransaction(tx *types.Transaction, block *types.Block, candidates map[string]bool) []TxEvent {
 	from, err := GetSenderAddress(tx)
 	if err != nil {
-		return
+		return nil
 	}
 	to := tx.To()
 	if to == nil {
-		return
+		return s.processContractCreation(tx, block, strings.ToLower(from), candidates)
 	}
 
 	fromStr := strings.ToLower(from)
 	toStr := strings.ToLower(to.Hex())
 
-	// Check if sender or receiver is in our address map
-	if userID, ok := s.addressMap[fromStr]; ok && addressSet[fromStr] {
-		s.logTransaction(userID, fromStr, toStr, tx, block)
-		s.publishTransaction(userID, fromStr, toStr, tx, block)
-	} else if userID, ok := s.addressMap[toStr]; ok && addressSet[toStr] {
-		s.logTransaction(userID, fromStr, toStr, tx, block)
-		s.publishTransaction(userID, fromStr, toStr, tx, block)
+	// Check if sender and receiver are in our address map
+	addresses := []string{fromStr}
+	if toStr != fromStr {
+		addresses = append(addresses, toStr)
+	}
+
+	var parties []monitoredParty
+	for _, address := range addresses {
+		if !candidates[address] {
+			continue
+		}
+		userID, ok := s.userID(address)
+		if !ok && address == toStr {
+			userID, ok = s.blobInboxUserID(tx, address)
+		}
+		if ok {
+			parties = append(parties, monitoredParty{address: address, userID: userID})
+		}
+	}
+
+	var events []TxEvent
+	for _, party := range parties {
+		event := s.newTxEvent(party.userID, fromStr, toStr, tx, block)
+		event.Direction = direction(party.address, fromStr, toStr)
+		event.CounterpartyUserID = counterpartyUserID(parties, party)
+		events = append(events, event)
+	}
+	if len(events) > 0 {
+		s.decodeInput(events, tx)
 	}
+	return events
 }
 
-// publishTransaction constructs a TxEvent, validates it and publishes to Kafka
-func (s *Scanner) publishTransaction(userID, from, to string, tx *types.Transaction, block *types.Block) {
-	event := TxEvent{
-		UserID:      userID,
-		From:        from,
-		To:          to,
-		AmountWei:   tx.Value().String(),
-		AmountEth:   WeiToEther(tx.Value()),
-		Hash:        tx.Hash().Hex(),
-		BlockNumber: block.Number().Uint64(),
-		Timestamp:   time.Unix(int64(block.Time()), 0).Format(time.RFC3339),
+// decodeInput adds the decoded input of a contract call to its events
+func (s *Scanner) decodeInput(events []TxEvent, tx *types.Transaction) {
+	call := s.abis.Decode(*tx.To(), tx.Data())
+	if call == nil {
+		return
 	}
 
-	if err := ValidateEvent(event); err != nil {
-		s.logger.Errorf("Invalid transaction event: ", err)
+	for i := range events {
+		events[i].Selector = call.Selector
+		events[i].Method = call.Selector
+		if call.Method != nil {
+			events[i].Method = call.Method.Name
+			events[i].MethodSignature = call.Method.Signature
+			events[i].Args = call.Arguments
+		}
 	}
+}
 
-	s.producer.PublishEvent(event)
-	metrics.KafkaEventsPublished.Inc()
+// newTxEvent constructs a TxEvent for a matched transaction
+func (s *Scanner) newTxEvent(userID, from, to string, tx *types.Transaction, block *types.Block) TxEvent {
+	return TxEvent{
+		BaseEvent: s.newBaseEvent(EventTypeTransaction, userID, block),
+		From:      from,
+		To:        to,
+		AmountWei: tx.Value().String(),
+		AmountEth: WeiToEther(tx.Value()),
+		Hash:      tx.Hash().Hex(),
+	}
+}
+
+// publishBlock publishes the events of a processed block and updates block metrics
+func (s *Scanner) publishBlock(block *types.Block, events []Event) {
+	blockNumber := block.NumberU64()
+	s.chain.add(blockNumber, block.Hash())
+
+	metrics.CurrentBlock.WithLabelValues(s.chainName).Set(float64(blockNumber))
+	metrics.BlocksProcessed.WithLabelValues(s.chainName).Inc()
+	metrics.TransactionsProcessed.WithLabelValues(s.chainName).Add(float64(len(block.Transactions())))
+
+	s.logger.Infow("Processing block",
+		"block", blockNumber,
+		"hash", block.Hash().Hex(),
+	)
+
+	if len(events) == 0 {
+		s.logger.Infof("No transactions detected from the list of addresses at block: %d", blockNumber)
+		return
+	}
+
+	for _, event := range events {
+		if txEvent, ok := event.(TxEvent); ok {
+			s.mempool.markMined(txEvent.Hash)
+		}
+		s.publishEvent(event.withState(s.eventState))
+	}
 }
 
-func (s *Scanner) logTransaction(userID, from, to string, tx *types.Transaction, block *types.Block) {
-	event := TxEvent{
-		UserID:      userID,
-		From:        from,
-		To:          to,
-		AmountWei:   tx.Value().String(),
-		AmountEth:   WeiToEther(tx.Value()),
-		Hash:        tx.Hash().Hex(),
-		BlockNumber: block.Number().Uint64(),
-		Timestamp:   time.Unix(int64(block.Time()), 0).Format(time.RFC3339),
+// publishEvent validates an event and publishes it to Kafka
+func (s *Scanner) publishEvent(event Event) {
+	s.logger.Infof("Event detected: %+v", event)
+
+	if err := event.validate(); err != nil {
+		s.logger.Errorf("Invalid event: %v", err)
 	}
 
-	s.logger.Infof("Transaction detected: %+v", event)
+	s.producer.PublishEvent(event)
+	metrics.KafkaEventsPublished.WithLabelValues(s.chainName).Inc()
+	s.recordPublished(event)
 }
 
 // WeiToEther converts wei amount to Ether
 func WeiToEther(wei *big.Int) string {
-	eth := new(big.Float).SetInt(wei)
-	eth.Quo(eth, big.NewFloat(1e18))
-	return eth.Text('f', 8)
+	return FormatUnits(wei, 18)
 }
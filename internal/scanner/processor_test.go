@@ -42,27 +42,51 @@ func TestScanner_ValidateEvent(t *testing.T) {
 		errorMsgs []string
 	}{
 		{
-			name:      "all fields valid",
-			event:     scanner.TxEvent{"user1", "fromAddr", "toAddr", "1000", "0.00000100", "0xhash", 1, "2025-08-27T12:00:00Z"},
+			name: "all fields valid",
+			event: scanner.TxEvent{
+				BaseEvent: scanner.BaseEvent{
+					UserID:      "user1",
+					BlockNumber: 1,
+					Timestamp:   "2025-08-27T12:00:00Z",
+				},
+				From:      "fromAddr",
+				To:        "toAddr",
+				AmountWei: "1000",
+				AmountEth: "0.00000100",
+				Hash:      "0xhash",
+			},
 			wantError: false,
 		},
 		{
-			name:      "missing UserID",
-			event:     scanner.TxEvent{"", "fromAddr", "toAddr", "1000", "0.00000100", "0xhash", 1, "2025-08-27T12:00:00Z"},
+			name: "missing UserID",
+			event: scanner.TxEvent{
+				BaseEvent: scanner.BaseEvent{
+					UserID:      "",
+					BlockNumber: 1,
+					Timestamp:   "2025-08-27T12:00:00Z",
+				},
+				From:      "fromAddr",
+				To:        "toAddr",
+				AmountWei: "1000",
+				AmountEth: "0.00000100",
+				Hash:      "0xhash",
+			},
 			wantError: true,
 			errorMsgs: []string{"userId is required"},
 		},
 		{
 			name: "missing multiple fields",
 			event: scanner.TxEvent{
-				UserID:      "",
-				From:        "",
-				To:          "",
-				AmountWei:   "",
-				AmountEth:   "",
-				Hash:        "",
-				BlockNumber: 0,
-				Timestamp:   "",
+				BaseEvent: scanner.BaseEvent{
+					UserID:      "",
+					BlockNumber: 0,
+					Timestamp:   "",
+				},
+				From:      "",
+				To:        "",
+				AmountWei: "",
+				AmountEth: "",
+				Hash:      "",
 			},
 			wantError: true,
 			errorMsgs: []string{
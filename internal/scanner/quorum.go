@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/rpcpool"
+)
+
+// errQuorumNotReached is returned when too few providers agree on a block hash.
+// The block is retried later rather than treated as a failure of the active provider.
+var errQuorumNotReached = errors.New("block hash quorum not reached")
+
+// quorumProvider is a secondary node used only to cross-check block hashes
+type quorumProvider struct {
+	name   string
+	client chain.ChainClient
+}
+
+// quorumVerifier requires a number of providers to agree on a block hash
+// before the block is processed
+type quorumVerifier struct {
+	providers []quorumProvider
+	size      int
+}
+
+// newQuorumVerifier dials the secondary providers. size is the number of
+// providers, including the primary, that must report the same block hash.
+func newQuorumVerifier(ctx context.Context, urls []string, size int, dial chain.DialFunc) (*quorumVerifier, error) {
+	if size > len(urls)+1 {
+		return nil, fmt.Errorf("quorum of %d cannot be reached with %d providers", size, len(urls)+1)
+	}
+
+	verifier := &quorumVerifier{size: size}
+	for i, rawURL := range urls {
+		client, err := dial(ctx, rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to quorum provider %d: %v", i, err)
+		}
+		verifier.providers = append(verifier.providers, quorumProvider{
+			name:   "quorum-" + rpcpool.ProviderName(rawURL, i),
+			client: client,
+		})
+	}
+	return verifier, nil
+}
+
+// verifyQuorum fetches the header of block from every secondary provider and
+// returns an error unless enough providers agree with the primary's hash
+func (s *Scanner) verifyQuorum(block *types.Block) error {
+	if s.quorum == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	number := block.Number()
+	hashes := make([]common.Hash, len(s.quorum.providers))
+	var wg sync.WaitGroup
+	for i, provider := range s.quorum.providers {
+		wg.Add(1)
+		go func(i int, provider quorumProvider) {
+			defer wg.Done()
+			header, err := provider.client.HeaderByNumber(ctx, new(big.Int).Set(number))
+			if err != nil {
+				s.logger.Debugf("Quorum provider %s failed to return block %d: %v", provider.name, number, err)
+				return
+			}
+			hashes[i] = header.Hash()
+		}(i, provider)
+	}
+	wg.Wait()
+
+	agree, disagree := countAgreement(block.Hash(), hashes)
+	for _, i := range disagree {
+		metrics.QuorumDisagreements.WithLabelValues(s.chainName, s.quorum.providers[i].name).Inc()
+		s.logger.Errorw("Providers disagree on block hash",
+			"block", number.Uint64(),
+			"primary", s.provider,
+			"primary_hash", block.Hash().Hex(),
+			"provider", s.quorum.providers[i].name,
+			"provider_hash", hashes[i].Hex(),
+		)
+	}
+
+	if agree < s.quorum.size {
+		metrics.QuorumFailures.WithLabelValues(s.chainName).Inc()
+		return fmt.Errorf("%w: block %d hash %s confirmed by %d of %d required providers",
+			errQuorumNotReached, number.Uint64(), block.Hash().Hex(), agree, s.quorum.size)
+	}
+	return nil
+}
+
+// countAgreement returns how many providers, counting the primary, agree with
+// the primary hash and the indexes of the secondaries that reported another hash.
+// Secondaries without an answer (zero hash) neither agree nor disagree.
+func countAgreement(primary common.Hash, secondary []common.Hash) (int, []int) {
+	agree := 1
+	var disagree []int
+	for i, hash := range secondary {
+		switch hash {
+		case common.Hash{}:
+		case primary:
+			agree++
+		default:
+			disagree = append(disagree, i)
+		}
+	}
+	return agree, disagree
+}
@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/logger"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_CountAgreement(t *testing.T) {
+	primary := common.HexToHash("0x01")
+	other := common.HexToHash("0x02")
+
+	tests := []struct {
+		name         string
+		secondary    []common.Hash
+		wantAgree    int
+		wantDisagree []int
+	}{
+		{"no secondaries", nil, 1, nil},
+		{"all agree", []common.Hash{primary, primary}, 3, nil},
+		{"one disagrees", []common.Hash{primary, other}, 2, []int{1}},
+		{"missing answers are ignored", []common.Hash{{}, primary}, 2, nil},
+		{"all disagree", []common.Hash{other, other}, 1, []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agree, disagree := countAgreement(primary, tt.secondary)
+			assert.Equal(t, tt.wantAgree, agree)
+			assert.Equal(t, tt.wantDisagree, disagree)
+		})
+	}
+}
+
+// errorLog is a no-op logger recording the messages logged with Errorw
+type errorLog struct {
+	logger.Logger
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *errorLog) Errorw(msg string, keysAndValues ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+func (l *errorLog) logged(msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Contains(l.messages, msg)
+}
+
+func TestScanner_QuorumHoldsBackDisagreedBlocks(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.QuorumNodeURLs = []string{"fake://quorum"}
+	cfg.QuorumSize = 2
+	primary, secondary := fakechain.New(1), fakechain.New(1)
+	env := newTestEnvWithChains(t, cfg, map[string]*fakechain.Chain{
+		"fake://primary": primary,
+		"fake://quorum":  secondary,
+	})
+	logs := &errorLog{Logger: logger.NewNoOpLogger()}
+	env.scanner.logger = logs
+	require.NoError(t, env.scanner.Start())
+
+	// Both providers agree on block 1
+	secondary.AppendBlock()
+	primary.AppendBlock()
+	require.Eventually(t, func() bool {
+		checkpoint, err := storage.ReadLastProcessedBlock(cfg.CheckpointFile)
+		return err == nil && checkpoint == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The chains diverge at block 2: the primary's block carries the transfer,
+	// the secondary's is empty. Forking both keeps their later blocks identical.
+	tx := env.transferToUser(t, 1)
+	primary.Fork(1)
+	secondary.AppendBlock()
+	disagreements := metrics.QuorumDisagreements.WithLabelValues(env.scanner.chainName, "quorum-0-quorum")
+	before := testutil.ToFloat64(disagreements)
+	heldBack := primary.AppendBlock(tx)
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(disagreements) > before
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, logs.logged("Providers disagree on block hash"))
+	assert.Empty(t, eventsOf[TxEvent](env.recorder), "the block should be held back")
+	checkpoint, err := storage.ReadLastProcessedBlock(cfg.CheckpointFile)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), checkpoint)
+
+	// The secondary reorgs onto the primary's block, which is processed with the next head
+	secondary.Fork(1)
+	require.Equal(t, heldBack.Hash(), secondary.AppendBlock(tx).Hash())
+	primary.AppendBlock()
+
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	assert.Equal(t, uint64(2), events[0].BlockNumber)
+	assert.Equal(t, heldBack.Hash().Hex(), events[0].BlockHash)
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Execution statuses taken from transaction receipts
+const (
+	TxStatusSuccess = "success"
+	TxStatusFailed  = "failed"
+)
+
+// applyReceipt adds the execution outcome and fees of a transaction to an event
+func applyReceipt(event *TxEvent, tx *types.Transaction, receipt *types.Receipt) {
+	event.Status = TxStatusSuccess
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		event.Status = TxStatusFailed
+	}
+	event.TxType = tx.Type()
+	event.GasUsed = receipt.GasUsed
+
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = tx.GasPrice()
+	}
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+
+	event.EffectiveGasPrice = gasPrice.String()
+	event.FeeWei = fee.String()
+	event.FeeEth = WeiToEther(fee)
+
+	if event.Type == EventTypeContractCreation && receipt.ContractAddress != (common.Address{}) {
+		event.ContractAddress = strings.ToLower(receipt.ContractAddress.Hex())
+		event.To = event.ContractAddress
+	}
+	applyBlobReceipt(event, tx, receipt)
+}
+
+// fetchReceipt fetches the receipt of a matched transaction
+func (s *Scanner) fetchReceipt(tx *types.Transaction) (*types.Receipt, error) {
+	receipt, err := s.client.TransactionReceipt(s.ctx, tx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt for %s: %v", tx.Hash().Hex(), err)
+	}
+	return receipt, nil
+}
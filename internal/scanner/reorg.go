@@ -0,0 +1,218 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+)
+
+// MaxReorgDepth is the number of recent canonical blocks tracked for reorg detection
+const MaxReorgDepth = 128
+
+// ErrReorgTooDeep is returned when the common ancestor of a reorg is beyond
+// the tracked window, so the reorg cannot be resolved by retrying
+var ErrReorgTooDeep = errors.New("reorg deeper than the tracked window")
+
+// canonicalChain keeps the hashes of recent canonical blocks by number
+type canonicalChain struct {
+	mu     sync.RWMutex
+	hashes map[uint64]common.Hash
+	head   uint64
+}
+
+func newCanonicalChain() *canonicalChain {
+	return &canonicalChain{
+		hashes: make(map[uint64]common.Hash),
+	}
+}
+
+// hash returns the canonical hash of a block if it is still tracked
+func (c *canonicalChain) hash(number uint64) (common.Hash, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	h, ok := c.hashes[number]
+	return h, ok
+}
+
+// headNumber returns the highest tracked block number
+func (c *canonicalChain) headNumber() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.head
+}
+
+// lowest returns the lowest tracked block number
+func (c *canonicalChain) lowest() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	lowest := c.head
+	for n := range c.hashes {
+		if n < lowest {
+			lowest = n
+		}
+	}
+	return lowest
+}
+
+// add records a canonical block and drops blocks older than MaxReorgDepth
+func (c *canonicalChain) add(number uint64, hash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hashes[number] = hash
+	if number <= c.head {
+		return
+	}
+
+	c.head = number
+	for n := range c.hashes {
+		if n+MaxReorgDepth < c.head {
+			delete(c.hashes, n)
+		}
+	}
+}
+
+// rewind forgets every block above number
+func (c *canonicalChain) rewind(number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for n := range c.hashes {
+		if n > number {
+			delete(c.hashes, n)
+		}
+	}
+	c.head = number
+}
+
+// isReorg reports whether header does not extend the tracked canonical chain
+func (c *canonicalChain) isReorg(header *types.Header) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	number := header.Number.Uint64()
+	if known, ok := c.hashes[number]; ok && known != header.Hash() {
+		return true
+	}
+	if number == 0 {
+		return false
+	}
+	if parent, ok := c.hashes[number-1]; ok && parent != header.ParentHash {
+		return true
+	}
+	return false
+}
+
+// handleReorg walks back from header to the common ancestor with the tracked
+// chain, switches the tracked chain over and retracts events of orphaned blocks.
+// It fails with ErrReorgTooDeep when the ancestor is beyond the tracked window.
+func (s *Scanner) handleReorg(header *types.Header) error {
+	newChain := []*types.Header{header}
+	current := header
+	for {
+		number := current.Number.Uint64()
+		if number == 0 {
+			return fmt.Errorf("%w: reorg at block %d replaced the genesis block", ErrReorgTooDeep, header.Number.Uint64())
+		}
+		if header.Number.Uint64()-number >= MaxReorgDepth {
+			return fmt.Errorf("%w: reorg at block %d is deeper than %d blocks", ErrReorgTooDeep, header.Number.Uint64(), MaxReorgDepth)
+		}
+
+		known, ok := s.chain.hash(number - 1)
+		if ok && known == current.ParentHash {
+			break
+		}
+		if !ok && number-1 < s.chain.lowest() {
+			// Blocks below the tracked window were never seen, so the new
+			// branch joins our chain there
+			break
+		}
+
+		// The parent is not tracked or was orphaned, keep walking back. This
+		// also covers blocks above our head when the new branch is longer.
+		parent, err := s.client.HeaderByHash(s.ctx, current.ParentHash)
+		if err != nil {
+			return fmt.Errorf("failed to get header %s: %v", current.ParentHash.Hex(), err)
+		}
+		newChain = append(newChain, parent)
+		current = parent
+	}
+
+	ancestor := current.Number.Uint64() - 1
+	oldHead := s.chain.headNumber()
+	if ancestor < oldHead {
+		s.logger.Warnw("Chain reorganization detected",
+			"ancestor", ancestor,
+			"old_head", oldHead,
+			"new_head", header.Number.Uint64(),
+			"depth", oldHead-ancestor,
+		)
+		metrics.Reorgs.WithLabelValues(s.chainName).Inc()
+	}
+
+	s.chain.rewind(ancestor)
+	for i := len(newChain) - 1; i >= 0; i-- {
+		s.chain.add(newChain[i].Number.Uint64(), newChain[i].Hash())
+	}
+
+	if ancestor < s.lastBlock {
+		s.retractBlocks(ancestor)
+	}
+	return nil
+}
+
+// retractBlocks publishes retracted events for every processed block above
+// ancestor, newest first, and moves the checkpoint back to the ancestor
+func (s *Scanner) retractBlocks(ancestor uint64) {
+	var retracted []Event
+
+	s.publishedMu.Lock()
+	for n := s.lastBlock; n > ancestor; n-- {
+		retracted = append(retracted, s.published[n]...)
+		delete(s.published, n)
+	}
+	s.publishedMu.Unlock()
+
+	for _, event := range retracted {
+		event = event.withState(EventStateRetracted)
+		s.logger.Warnf("Event retracted: %+v", event)
+		s.producer.PublishEvent(event)
+		metrics.KafkaEventsPublished.WithLabelValues(s.chainName).Inc()
+		metrics.EventsRetracted.WithLabelValues(s.chainName).Inc()
+	}
+
+	s.confirmedUpTo = min(s.confirmedUpTo, ancestor)
+	s.commitBlock(ancestor)
+}
+
+// recordPublished remembers an event so it can be retracted on a reorg
+func (s *Scanner) recordPublished(event Event) {
+	s.publishedMu.Lock()
+	defer s.publishedMu.Unlock()
+
+	blockNumber := event.eventBlock()
+	s.published[blockNumber] = append(s.published[blockNumber], event)
+}
+
+// prunePublished forgets events of blocks that are too deep to be reorged
+func (s *Scanner) prunePublished(blockNumber uint64) {
+	s.publishedMu.Lock()
+	defer s.publishedMu.Unlock()
+
+	for n := range s.published {
+		// In lifecycle mode events are kept until they are published as finalized
+		if s.lifecycle && n > s.finalizedUpTo {
+			continue
+		}
+		if n+MaxReorgDepth < blockNumber {
+			delete(s.published, n)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHeader(number uint64, parent common.Hash, extra byte) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		ParentHash: parent,
+		Extra:      []byte{extra},
+	}
+}
+
+func TestScanner_CanonicalChainReorg(t *testing.T) {
+	chain := newCanonicalChain()
+
+	h1 := newTestHeader(1, common.Hash{}, 0)
+	h2 := newTestHeader(2, h1.Hash(), 0)
+	chain.add(1, h1.Hash())
+	chain.add(2, h2.Hash())
+
+	tests := []struct {
+		name   string
+		header *types.Header
+		reorg  bool
+	}{
+		{"extends head", newTestHeader(3, h2.Hash(), 0), false},
+		{"unknown parent", newTestHeader(5, common.HexToHash("0x01"), 0), false},
+		{"parent mismatch", newTestHeader(3, common.HexToHash("0x01"), 0), true},
+		{"replaces known block", newTestHeader(2, h1.Hash(), 1), true},
+		{"same block again", h2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.reorg, chain.isReorg(tt.header))
+		})
+	}
+}
+
+func TestScanner_CanonicalChainWindow(t *testing.T) {
+	chain := newCanonicalChain()
+	for n := uint64(1); n <= MaxReorgDepth+10; n++ {
+		chain.add(n, common.BigToHash(new(big.Int).SetUint64(n)))
+	}
+
+	_, ok := chain.hash(1)
+	assert.False(t, ok, "blocks older than the window should be pruned")
+	_, ok = chain.hash(MaxReorgDepth + 10)
+	assert.True(t, ok)
+
+	chain.rewind(100)
+	assert.Equal(t, uint64(100), chain.head)
+	_, ok = chain.hash(101)
+	assert.False(t, ok, "blocks above the rewind point should be dropped")
+	_, ok = chain.hash(100)
+	assert.True(t, ok)
+}
@@ -0,0 +1,140 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"go.uber.org/multierr"
+)
+
+// BlockRewardEvent represents execution layer rewards earned by a monitored
+// fee recipient: the priority fees of a block it is the coinbase of, or the
+// MEV payment a builder sent it in the last transaction of the block
+type BlockRewardEvent struct {
+	BaseEvent
+	FeeRecipient    string `json:"feeRecipient"`
+	Coinbase        string `json:"coinbase"`
+	RewardWei       string `json:"rewardWei"`
+	RewardEth       string `json:"rewardEth"`
+	PriorityFeesWei string `json:"priorityFeesWei,omitempty"`
+	PriorityFeesEth string `json:"priorityFeesEth,omitempty"`
+	MevPaymentHash  string `json:"mevPaymentHash,omitempty"`
+	MevPaymentWei   string `json:"mevPaymentWei,omitempty"`
+	MevPaymentEth   string `json:"mevPaymentEth,omitempty"`
+	Valuation
+}
+
+// validate checks that all the required fields of a block reward event are present
+func (e BlockRewardEvent) validate() error {
+	var validatorErr error
+	if e.UserID == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("userId is required"))
+	}
+	if e.FeeRecipient == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("feeRecipient is required"))
+	}
+	if e.RewardWei == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("rewardWei is required"))
+	}
+	if e.BlockNumber == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("blockNumber is required"))
+	}
+	return validatorErr
+}
+
+func (e BlockRewardEvent) withState(state string) Event { e.State = state; return e }
+
+// matchBlockRewards returns reward events for a monitored coinbase and for a
+// monitored recipient of the block's MEV payment
+func (s *Scanner) matchBlockRewards(block *types.Block) ([]Event, error) {
+	coinbase := strings.ToLower(block.Coinbase().Hex())
+	var events []Event
+
+	if userID, ok := s.monitored(coinbase); ok {
+		fees, err := s.priorityFees(block)
+		if err != nil {
+			return nil, err
+		}
+		event := s.newBlockRewardEvent(block, userID, coinbase, fees)
+		event.PriorityFeesWei = fees.String()
+		event.PriorityFeesEth = WeiToEther(fees)
+		events = append(events, event)
+	}
+
+	if payment := mevPayment(block); payment != nil {
+		recipient := strings.ToLower(payment.To().Hex())
+		if userID, ok := s.monitored(recipient); ok {
+			event := s.newBlockRewardEvent(block, userID, recipient, payment.Value())
+			event.MevPaymentHash = payment.Hash().Hex()
+			event.MevPaymentWei = payment.Value().String()
+			event.MevPaymentEth = WeiToEther(payment.Value())
+			events = append(events, event)
+		}
+	}
+
+	metrics.BlockRewardsDetected.WithLabelValues(s.chainName).Add(float64(len(events)))
+	return events, nil
+}
+
+func (s *Scanner) newBlockRewardEvent(block *types.Block, userID, feeRecipient string, reward *big.Int) BlockRewardEvent {
+	return BlockRewardEvent{
+		BaseEvent:    s.newBaseEvent(EventTypeBlockReward, userID, block),
+		FeeRecipient: feeRecipient,
+		Coinbase:     strings.ToLower(block.Coinbase().Hex()),
+		RewardWei:    reward.String(),
+		RewardEth:    WeiToEther(reward),
+	}
+}
+
+// priorityFees sums the tips paid to the coinbase by all transactions of a block.
+// The base fee part of the gas price is burnt and not earned.
+func (s *Scanner) priorityFees(block *types.Block) (*big.Int, error) {
+	total := new(big.Int)
+	if len(block.Transactions()) == 0 {
+		return total, nil
+	}
+
+	receipts, err := s.client.BlockReceipts(s.ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipts of block %d: %v", block.NumberU64(), err)
+	}
+
+	baseFee := block.BaseFee()
+	if baseFee == nil {
+		baseFee = new(big.Int)
+	}
+	for i, receipt := range receipts {
+		gasPrice := receipt.EffectiveGasPrice
+		if gasPrice == nil && i < len(block.Transactions()) {
+			gasPrice = block.Transactions()[i].GasPrice()
+		}
+		tip := new(big.Int).Sub(gasPrice, baseFee)
+		if tip.Sign() <= 0 {
+			continue
+		}
+		total.Add(total, tip.Mul(tip, new(big.Int).SetUint64(receipt.GasUsed)))
+	}
+	return total, nil
+}
+
+// mevPayment returns the last transaction of a block when it is a value
+// transfer sent by the coinbase, which is how builders pay the proposer
+func mevPayment(block *types.Block) *types.Transaction {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return nil
+	}
+	last := txs[len(txs)-1]
+	if last.To() == nil || last.Value().Sign() <= 0 || *last.To() == block.Coinbase() {
+		return nil
+	}
+	from, err := GetSenderAddress(last)
+	if err != nil || from != strings.ToLower(block.Coinbase().Hex()) {
+		return nil
+	}
+	return last
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_DetectsPriorityFeeRewards(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	env.chain.SetCoinbase(env.user)
+	block := env.chain.AppendBlock(env.transferToUser(t, 1), env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken}))
+
+	events := waitFor[BlockRewardEvent](t, env.recorder, 1)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeBlockReward, events[0].Type)
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Equal(t, strings.ToLower(env.user.Hex()), events[0].FeeRecipient)
+
+	// A 1 gwei tip on 21000 and 60000 gas
+	assert.Equal(t, "81000000000000", events[0].PriorityFeesWei)
+	assert.Equal(t, events[0].PriorityFeesWei, events[0].RewardWei)
+	assert.Empty(t, events[0].MevPaymentHash)
+	assert.Equal(t, block.Hash().Hex(), events[0].BlockHash)
+}
+
+func TestScanner_DetectsMevPayments(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	// The builder is the coinbase and pays the proposer in the last transaction
+	builder := crypto.PubkeyToAddress(env.key.PublicKey)
+	env.chain.SetCoinbase(builder)
+	payment := env.transferToUser(t, 5e17)
+	env.chain.AppendBlock(env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken}), payment)
+
+	events := waitFor[BlockRewardEvent](t, env.recorder, 1)
+	require.Len(t, events, 1)
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Equal(t, strings.ToLower(env.user.Hex()), events[0].FeeRecipient)
+	assert.Equal(t, strings.ToLower(builder.Hex()), events[0].Coinbase)
+	assert.Equal(t, payment.Hash().Hex(), events[0].MevPaymentHash)
+	assert.Equal(t, "500000000000000000", events[0].MevPaymentWei)
+	assert.Equal(t, "0.50000000", events[0].RewardEth)
+	assert.Empty(t, events[0].PriorityFeesWei)
+
+	// The payment is also an incoming transaction of the recipient, flagged so it is not credited twice
+	txs := waitFor[TxEvent](t, env.recorder, 1)
+	require.Len(t, txs, 1)
+	assert.Equal(t, payment.Hash().Hex(), txs[0].Hash)
+	assert.True(t, txs[0].MevPayment)
+}
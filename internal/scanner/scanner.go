@@ -2,88 +2,207 @@ package scanner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/abiregistry"
 	"github.com/nagdahimanshu/ethereum-block-scanner/internal/bloom"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
 	"github.com/nagdahimanshu/ethereum-block-scanner/internal/config"
 	kafka "github.com/nagdahimanshu/ethereum-block-scanner/internal/events"
 	"github.com/nagdahimanshu/ethereum-block-scanner/internal/logger"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/pricing"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/rpcpool"
 	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
 )
 
 // Scanner is the main struct for Ethereum block scanning
 type Scanner struct {
-	client         *ethclient.Client
+	chainName      string
+	chainID        uint64
+	client         chain.ChainClient
+	pool           *rpcpool.Pool
+	provider       string
+	quorum         *quorumVerifier
 	bloomFilter    *bloom.AddressBloomFilter
 	addressMap     map[string]string
-	nodeURL        string
+	addressMu      sync.RWMutex
+	addressVersion atomic.Uint64 // Incremented whenever an address is watched
+	addressesFile  string
+	headSource     string
+	polling        bool
+	pollInterval   time.Duration
 	headersChan    chan *types.Header
 	ctx            context.Context
 	checkpointFile string
 	lastBlock      uint64
+	concurrency    int
+	finality       string
+	confirmations  uint64
+	eventState     string
+	lifecycle      bool
+	confirmedUpTo  uint64
+	finalizedUpTo  uint64
+	skipFailedTxs  bool
+	traceInternal  bool
+	tracing        atomic.Bool
+	watchDeployed  bool
+	blobInboxes    map[string]string
+	tokenCache     *tokenMetadataCache
+	prices         pricing.PriceSource
+	abis           *abiregistry.Registry
+	chain          *canonicalChain
+	reorgPending   bool // Set while a detected reorg is retried on the next header
+	published      map[uint64][]Event
+	mempool        *mempool
+	publishedMu    sync.Mutex
 	//nolint:typecheck
 	subscription ethereum.Subscription
 	logger       logger.Logger
-	producer     *kafka.KafkaProducer
+	producer     kafka.Publisher
+	retryDelay   time.Duration
 }
 
-// New initializes a new Scanner instance
-func New(ctx context.Context, cfg *config.Config, logger logger.Logger, bloomFilter *bloom.AddressBloomFilter, addressMap map[string]string, producer *kafka.KafkaProducer) (*Scanner, error) {
-	client, err := ethclient.Dial(cfg.EthereumNodeURL)
+// New initializes a new Scanner instance connected to the configured Ethereum nodes
+func New(ctx context.Context, cfg *config.Config, logger logger.Logger, bloomFilter *bloom.AddressBloomFilter, addressMap map[string]string, producer kafka.Publisher) (*Scanner, error) {
+	return NewWithDialer(ctx, cfg, logger, bloomFilter, addressMap, producer, chain.Dial)
+}
+
+// NewWithDialer initializes a new Scanner instance that connects to nodes through
+// dial, which lets tests run the scanner against an in-memory chain
+func NewWithDialer(ctx context.Context, cfg *config.Config, logger logger.Logger, bloomFilter *bloom.AddressBloomFilter, addressMap map[string]string, producer kafka.Publisher, dial chain.DialFunc) (*Scanner, error) {
+	pool, err := rpcpool.New(ctx, logger, cfg.Chain, cfg.EthereumNodeURLs, uint64(cfg.ProviderMaxLag), dial)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %v", err)
 	}
+	go pool.Run(ctx, cfg.ProviderHealthInterval)
+	active := pool.Active()
+
+	chainID, err := active.Client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain id: %v", err)
+	}
+	if cfg.ChainID != 0 && chainID.Uint64() != uint64(cfg.ChainID) {
+		return nil, fmt.Errorf("node of chain %s reports chain id %d, expected %d", cfg.Chain, chainID, cfg.ChainID)
+	}
+
+	finality := strings.ToLower(cfg.FinalityPolicy)
+	eventState, err := finalityState(finality)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LifecycleEvents {
+		eventState = EventStateIncluded
+	}
+
+	var quorum *quorumVerifier
+	if len(cfg.QuorumNodeURLs) > 0 {
+		quorum, err = newQuorumVerifier(ctx, cfg.QuorumNodeURLs, int(cfg.QuorumSize), dial)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	blobInboxes, err := parseBlobInboxes(cfg.BlobInboxes)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenCache, err := newTokenMetadataCache(cfg.TokenMetadataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token metadata: %v", err)
+	}
+
+	prices, err := newPriceSource(cfg, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	abis, err := abiregistry.Load(cfg.ABIDir, cfg.SelectorsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ABIs: %v", err)
+	}
 
 	lastBlock, err := storage.ReadLastProcessedBlock(cfg.CheckpointFile)
 	if err != nil {
 		logger.Infof("Could not read checkpoint: %v", err)
 	}
 
-	return &Scanner{
-		client:         client,
+	s := &Scanner{
+		chainName:      cfg.Chain,
+		chainID:        chainID.Uint64(),
+		client:         active.Client,
+		pool:           pool,
+		provider:       active.Name,
+		quorum:         quorum,
 		bloomFilter:    bloomFilter,
 		addressMap:     addressMap,
-		nodeURL:        cfg.EthereumNodeURL,
+		addressesFile:  cfg.WatchedAddressesFile,
+		headSource:     cfg.HeadSource,
+		pollInterval:   cfg.PollInterval,
 		headersChan:    make(chan *types.Header),
 		ctx:            ctx,
 		checkpointFile: cfg.CheckpointFile,
 		lastBlock:      lastBlock,
+		concurrency:    int(cfg.FetchConcurrency),
+		finality:       finality,
+		confirmations:  uint64(cfg.Confirmations),
+		eventState:     eventState,
+		lifecycle:      cfg.LifecycleEvents,
+		skipFailedTxs:  cfg.SkipFailedTxs,
+		traceInternal:  cfg.TraceInternalTransfers,
+		watchDeployed:  cfg.WatchDeployedContracts,
+		blobInboxes:    blobInboxes,
+		tokenCache:     tokenCache,
+		prices:         prices,
+		abis:           abis,
+		chain:          newCanonicalChain(),
+		published:      make(map[uint64][]Event),
 		logger:         logger,
 		producer:       producer,
-	}, nil
+		retryDelay:     5 * time.Second,
+	}
+	s.tracing.Store(s.traceInternal)
+	return s, nil
 }
 
-// tryReconnect attempts to reconnect to the Ethereum node on connection failure
+// tryReconnect resubscribes on the pool's active provider after a connection
+// failure, failing over to the next provider while the active one keeps failing
 func (s *Scanner) tryReconnect() {
 	s.Stop()
+	metrics.Reconnections.WithLabelValues(s.chainName).Inc()
 
 	for {
-		client, err := ethclient.Dial(s.nodeURL)
-		if err != nil {
+		if err := s.subscribe(); err != nil {
 			s.logger.Warnw("Failed to reconnect",
 				"error", err,
-				"retry_in", "5s",
-				"node", s.nodeURL,
+				"retry_in", s.retryDelay,
+				"provider", s.provider,
 			)
-			time.Sleep(5 * time.Second)
+			s.pool.MarkFailed(s.provider, err)
+			time.Sleep(s.retryDelay)
 			continue
 		}
 
-		s.client = client
-		s.headersChan = make(chan *types.Header)
+		s.logger.Infow("Successfully reconnected to Ethereum node", "provider", s.provider)
+		break
+	}
 
-		if err := s.Start(); err != nil {
-			s.logger.Infof("Failed to restart watcher: %v. Retrying...", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
+	go s.processHeaders()
+}
 
-		s.logger.Infof("Successfully reconnected to Ethereum node")
-		break
+// reportError fails over to another provider when the active one errors
+func (s *Scanner) reportError(err error) {
+	if s.ctx.Err() != nil || errors.Is(err, errQuorumNotReached) {
+		return
 	}
+	s.pool.MarkFailed(s.provider, err)
 }
@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"go.uber.org/multierr"
+)
+
+// TransferTopic is the topic of the ERC-20 Transfer(address,address,uint256) event
+var TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// TokenEvent represents a token transfer involving a monitored address
+type TokenEvent struct {
+	BaseEvent
+	Direction          string `json:"direction"`
+	CounterpartyUserID string `json:"counterpartyUserId,omitempty"`
+	Token              string `json:"token"`
+	From               string `json:"from"`
+	To                 string `json:"to"`
+	Amount             string `json:"amount"`
+	AmountFormatted    string `json:"amountFormatted,omitempty"`
+	Symbol             string `json:"symbol,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Decimals           *uint8 `json:"decimals,omitempty"`
+	LogIndex           uint   `json:"logIndex"`
+	Hash               string `json:"hash"`
+	Valuation
+}
+
+// validate checks that all the required fields of a token event are present
+func (e TokenEvent) validate() error {
+	var validatorErr error
+	if e.UserID == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("userId is required"))
+	}
+	if e.Token == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("token is required"))
+	}
+	if e.Amount == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("amount is required"))
+	}
+	if e.Hash == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("hash is required"))
+	}
+	if e.BlockNumber == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("blockNumber is required"))
+	}
+	return validatorErr
+}
+
+func (e TokenEvent) withState(state string) Event { e.State = state; return e }
+
+// fetchLogs returns the logs of a block with one of the given first topics.
+// The header bloom is checked first so blocks without such logs cost no RPC call.
+func (s *Scanner) fetchLogs(block *types.Block, topics ...common.Hash) ([]types.Log, error) {
+	bloom := block.Bloom()
+	var present []common.Hash
+	for _, topic := range topics {
+		if types.BloomLookup(bloom, topic) {
+			present = append(present, topic)
+		}
+	}
+	if len(present) == 0 {
+		return nil, nil
+	}
+
+	hash := block.Hash()
+	logs, err := s.client.FilterLogs(s.ctx, ethereum.FilterQuery{
+		BlockHash: &hash,
+		Topics:    [][]common.Hash{present},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of block %d: %v", block.NumberU64(), err)
+	}
+	return logs, nil
+}
+
+// matchTokenTransfers returns an event for every monitored party of the
+// ERC-20 Transfer logs of a block
+func (s *Scanner) matchTokenTransfers(block *types.Block, logs []types.Log) []Event {
+	var events []Event
+	for _, log := range logs {
+		from, to, amount, ok := decodeERC20Transfer(log)
+		if !ok {
+			continue
+		}
+
+		parties := s.monitoredParties(from, to)
+		if len(parties) == 0 {
+			continue
+		}
+
+		token := strings.ToLower(log.Address.Hex())
+		metadata := s.tokenMetadata(token)
+		var formatted string
+		if metadata.Decimals != nil {
+			formatted = FormatUnits(amount, *metadata.Decimals)
+		}
+		for _, party := range parties {
+			events = append(events, TokenEvent{
+				BaseEvent:          s.newBaseEvent(EventTypeTokenTransfer, party.userID, block),
+				Direction:          direction(party.address, from, to),
+				CounterpartyUserID: counterpartyUserID(parties, party),
+				Token:              token,
+				From:               from,
+				To:                 to,
+				Amount:             amount.String(),
+				AmountFormatted:    formatted,
+				Symbol:             metadata.Symbol,
+				Name:               metadata.Name,
+				Decimals:           metadata.Decimals,
+				LogIndex:           log.Index,
+				Hash:               log.TxHash.Hex(),
+			})
+			metrics.TokenTransfersDetected.WithLabelValues(s.chainName).Inc()
+		}
+	}
+	return events
+}
+
+// decodeERC20Transfer decodes an ERC-20 Transfer log. ERC-721 transfers share
+// the topic but index the token id, so they carry four topics and no data.
+func decodeERC20Transfer(log types.Log) (from, to string, amount *big.Int, ok bool) {
+	if log.Removed || len(log.Topics) != 3 || log.Topics[0] != TransferTopic || len(log.Data) != 32 {
+		return "", "", nil, false
+	}
+	from = topicAddress(log.Topics[1])
+	to = topicAddress(log.Topics[2])
+	return from, to, new(big.Int).SetBytes(log.Data), true
+}
+
+// topicAddress returns the lowercase hex address stored in an indexed topic
+func topicAddress(topic common.Hash) string {
+	return strings.ToLower(common.BytesToAddress(topic.Bytes()).Hex())
+}
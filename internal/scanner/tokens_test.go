@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testToken = common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+
+// erc20Transfer returns a Transfer log of amount tokens from one address to another
+func erc20Transfer(token, from, to common.Address, amount int64) *types.Log {
+	return &types.Log{
+		Address: token,
+		Topics:  []common.Hash{TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+	}
+}
+
+func TestScanner_DecodeERC20Transfer(t *testing.T) {
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	erc721 := erc20Transfer(testToken, from, to, 0)
+	erc721.Topics = append(erc721.Topics, common.BigToHash(big.NewInt(7)))
+	erc721.Data = nil
+
+	approval := erc20Transfer(testToken, from, to, 5)
+	approval.Topics[0] = common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+
+	tests := []struct {
+		name   string
+		log    *types.Log
+		wantOk bool
+	}{
+		{"erc20 transfer", erc20Transfer(testToken, from, to, 5), true},
+		{"erc721 transfer", erc721, false},
+		{"other event", approval, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotFrom, gotTo, amount, ok := decodeERC20Transfer(*tt.log)
+			assert.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				return
+			}
+			assert.Equal(t, strings.ToLower(from.Hex()), gotFrom)
+			assert.Equal(t, strings.ToLower(to.Hex()), gotTo)
+			assert.Equal(t, "5", amount.String())
+		})
+	}
+}
+
+func TestScanner_DetectsTokenTransfers(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	tx := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken})
+	env.chain.AddLogs(tx.Hash(),
+		erc20Transfer(testToken, other, env.user, 250),
+		erc20Transfer(testToken, other, other, 1),
+		erc20Transfer(testToken, env.user, other, 100),
+		erc20Transfer(testToken, env.user, env.user, 3),
+	)
+	block := env.chain.AppendBlock(tx)
+
+	events := waitFor[TokenEvent](t, env.recorder, 3)
+
+	assert.Equal(t, []string{DirectionIncoming, DirectionOutgoing, DirectionSelf},
+		[]string{events[0].Direction, events[1].Direction, events[2].Direction})
+	assert.Equal(t, []uint{0, 2, 3}, []uint{events[0].LogIndex, events[1].LogIndex, events[2].LogIndex})
+
+	assert.Equal(t, EventTypeTokenTransfer, events[0].Type)
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Equal(t, strings.ToLower(testToken.Hex()), events[0].Token)
+	assert.Equal(t, "250", events[0].Amount)
+	assert.Equal(t, tx.Hash().Hex(), events[0].Hash)
+	assert.Equal(t, block.Hash().Hex(), events[0].BlockHash)
+	assert.Equal(t, EventStateConfirmed, events[0].State)
+
+	// The call itself does not involve the monitored address
+	assert.Empty(t, eventsOf[TxEvent](env.recorder))
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"go.uber.org/multierr"
+)
+
+// InternalTransferEvent represents ETH moved to or from a monitored address by
+// a nested call of a transaction
+type InternalTransferEvent struct {
+	BaseEvent
+	Direction          string `json:"direction"`
+	CounterpartyUserID string `json:"counterpartyUserId,omitempty"`
+	From               string `json:"from"`
+	To                 string `json:"to"`
+	AmountWei          string `json:"amountWei"`
+	AmountEth          string `json:"amountEth"`
+	CallType           string `json:"callType"`
+	TracePath          []int  `json:"tracePath"`
+	Hash               string `json:"hash"`
+	Valuation
+}
+
+// validate checks that all the required fields of an internal transfer event are present
+func (e InternalTransferEvent) validate() error {
+	var validatorErr error
+	if e.UserID == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("userId is required"))
+	}
+	if e.AmountWei == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("amountWei is required"))
+	}
+	if len(e.TracePath) == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("tracePath is required"))
+	}
+	if e.Hash == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("hash is required"))
+	}
+	if e.BlockNumber == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("blockNumber is required"))
+	}
+	return validatorErr
+}
+
+func (e InternalTransferEvent) withState(state string) Event { e.State = state; return e }
+
+// internalTransfer is a value-bearing nested call found in a call trace
+type internalTransfer struct {
+	frame chain.CallFrame
+	path  []int
+}
+
+// matchInternalTransfers traces a block and returns an event for every monitored
+// party of its nested value transfers. Tracing is switched off until the next
+// (re)connect when the node does not serve the debug namespace.
+func (s *Scanner) matchInternalTransfers(block *types.Block) ([]Event, error) {
+	if !s.tracing.Load() || len(block.Transactions()) == 0 {
+		return nil, nil
+	}
+
+	traces, err := chain.TraceBlock(s.ctx, s.client, block.Hash())
+	if errors.Is(err, chain.ErrTracingUnsupported) {
+		if s.tracing.CompareAndSwap(true, false) {
+			s.logger.Warnw("Node does not support call tracing, internal transfers are not detected",
+				"provider", s.provider,
+			)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to trace block %d: %v", block.NumberU64(), err)
+	}
+
+	var events []Event
+	for _, trace := range traces {
+		// A reverted transaction reverts all of its nested calls
+		if trace.Error != "" || trace.Result.Error != "" {
+			continue
+		}
+
+		for _, transfer := range internalTransfers(trace.Result.Calls, nil) {
+			from := strings.ToLower(transfer.frame.From.Hex())
+			to := strings.ToLower(transfer.frame.To.Hex())
+			value := transfer.frame.Value.ToInt()
+
+			parties := s.monitoredParties(from, to)
+			for _, party := range parties {
+				events = append(events, InternalTransferEvent{
+					BaseEvent:          s.newBaseEvent(EventTypeInternal, party.userID, block),
+					Direction:          direction(party.address, from, to),
+					CounterpartyUserID: counterpartyUserID(parties, party),
+					From:               from,
+					To:                 to,
+					AmountWei:          value.String(),
+					AmountEth:          WeiToEther(value),
+					CallType:           transfer.frame.Type,
+					TracePath:          transfer.path,
+					Hash:               trace.TxHash.Hex(),
+				})
+				metrics.InternalTransfersDetected.WithLabelValues(s.chainName).Inc()
+			}
+		}
+	}
+	return events, nil
+}
+
+// internalTransfers walks nested call frames depth first and returns the ones
+// moving ETH, with the index path leading to them from the top-level call.
+// Reverted frames are skipped together with their subcalls.
+func internalTransfers(frames []chain.CallFrame, path []int) []internalTransfer {
+	var transfers []internalTransfer
+	for i, frame := range frames {
+		if frame.Error != "" {
+			continue
+		}
+
+		framePath := append(slices.Clone(path), i)
+		if movesValue(frame) {
+			transfers = append(transfers, internalTransfer{frame: frame, path: framePath})
+		}
+		transfers = append(transfers, internalTransfers(frame.Calls, framePath)...)
+	}
+	return transfers
+}
+
+// movesValue reports whether a call frame transfers ETH to another account.
+// DELEGATECALL and CALLCODE run in the caller's context and move no ETH.
+func movesValue(frame chain.CallFrame) bool {
+	if frame.To == nil || frame.Value == nil || frame.Value.ToInt().Cmp(big.NewInt(0)) <= 0 {
+		return false
+	}
+	switch strings.ToUpper(frame.Type) {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRouter = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+
+func callFrame(callType string, from, to common.Address, value int64, calls ...chain.CallFrame) chain.CallFrame {
+	return chain.CallFrame{
+		Type:  callType,
+		From:  from,
+		To:    &to,
+		Value: (*hexutil.Big)(big.NewInt(value)),
+		Calls: calls,
+	}
+}
+
+func TestScanner_InternalTransfers(t *testing.T) {
+	a := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	b := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	reverted := callFrame("CALL", a, b, 5, callFrame("CALL", b, a, 6))
+	reverted.Error = "execution reverted"
+
+	frames := []chain.CallFrame{
+		callFrame("STATICCALL", a, b, 0),
+		callFrame("CALL", a, b, 1,
+			callFrame("DELEGATECALL", b, a, 2),
+			callFrame("CALL", b, a, 3),
+		),
+		reverted,
+		callFrame("CREATE2", a, b, 4),
+	}
+
+	var values []int64
+	var paths [][]int
+	for _, transfer := range internalTransfers(frames, nil) {
+		values = append(values, transfer.frame.Value.ToInt().Int64())
+		paths = append(paths, transfer.path)
+	}
+	assert.Equal(t, []int64{1, 3, 4}, values)
+	assert.Equal(t, [][]int{{1}, {1, 1}, {3}}, paths)
+}
+
+func TestScanner_DetectsInternalTransfers(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.TraceInternalTransfers = true
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	multisig := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	tx := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testRouter})
+	env.chain.SetTrace(tx.Hash(), callFrame("CALL", common.Address{}, testRouter, 0,
+		callFrame("CALL", testRouter, multisig, 0,
+			callFrame("CALL", multisig, env.user, 2e17),
+		),
+	))
+	block := env.chain.AppendBlock(tx)
+
+	events := waitFor[InternalTransferEvent](t, env.recorder, 1)
+
+	assert.Equal(t, EventTypeInternal, events[0].Type)
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Equal(t, DirectionIncoming, events[0].Direction)
+	assert.Equal(t, strings.ToLower(multisig.Hex()), events[0].From)
+	assert.Equal(t, "200000000000000000", events[0].AmountWei)
+	assert.Equal(t, "0.20000000", events[0].AmountEth)
+	assert.Equal(t, "CALL", events[0].CallType)
+	assert.Equal(t, []int{0, 0}, events[0].TracePath)
+	assert.Equal(t, tx.Hash().Hex(), events[0].Hash)
+	assert.Equal(t, block.Hash().Hex(), events[0].BlockHash)
+}
+
+func TestScanner_TracingDegradesWithoutDebugNamespace(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.TraceInternalTransfers = true
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	env.chain.DisableTracing()
+	require.NoError(t, env.scanner.Start())
+
+	env.chain.AppendBlock(env.transferToUser(t, 1))
+	waitFor[TxEvent](t, env.recorder, 1)
+
+	assert.False(t, env.scanner.tracing.Load())
+	assert.Empty(t, eventsOf[InternalTransferEvent](env.recorder))
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/config"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/pricing"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/rpcpool"
+)
+
+// Price sources selectable with PRICE_SOURCE
+const (
+	PriceSourceNone      = "none"
+	PriceSourceFile      = "file"
+	PriceSourceChainlink = "chainlink"
+)
+
+// Valuation is the USD value of the amount of an event and the price it was
+// computed with. When no price is available only PriceError is set.
+type Valuation struct {
+	AmountUSD   string `json:"amountUsd,omitempty"`
+	PriceUSD    string `json:"priceUsd,omitempty"`
+	PriceSource string `json:"priceSource,omitempty"`
+	PriceError  string `json:"priceError,omitempty"`
+}
+
+// newPriceSource returns the configured price source, or nil when valuation is disabled
+func newPriceSource(cfg *config.Config, pool *rpcpool.Pool) (pricing.PriceSource, error) {
+	switch strings.ToLower(cfg.PriceSource) {
+	case "", PriceSourceNone:
+		return nil, nil
+	case PriceSourceFile:
+		return pricing.NewFileSource(cfg.PriceFile)
+	case PriceSourceChainlink:
+		return pricing.NewChainlinkSource(pool, cfg.ChainlinkFeeds)
+	default:
+		return nil, fmt.Errorf("unknown price source %q, expected %s, %s or %s",
+			cfg.PriceSource, PriceSourceNone, PriceSourceFile, PriceSourceChainlink)
+	}
+}
+
+// valueEvents adds the USD valuation of their amount to the events of a block.
+// Prices are looked up once per asset and block.
+func (s *Scanner) valueEvents(block *types.Block, events []Event) {
+	if s.prices == nil {
+		return
+	}
+
+	prices := make(map[string]*big.Float)
+	errs := make(map[string]error)
+	value := func(asset, amount string, decimals *uint8) Valuation {
+		valuation := Valuation{PriceSource: s.prices.Name()}
+		raw, ok := new(big.Int).SetString(amount, 10)
+		if !ok || decimals == nil {
+			metrics.PricesUnavailable.WithLabelValues(s.chainName).Inc()
+			valuation.PriceError = "amount has unknown decimals"
+			return valuation
+		}
+
+		price, cached := prices[asset]
+		err := errs[asset]
+		if !cached && err == nil {
+			price, err = s.prices.Price(s.ctx, asset, block.NumberU64())
+			prices[asset], errs[asset] = price, err
+		}
+		if err != nil {
+			if !errors.Is(err, pricing.ErrNoPrice) {
+				s.logger.Warnw("Failed to get price", "asset", asset, "block", block.NumberU64(), "error", err)
+			}
+			metrics.PricesUnavailable.WithLabelValues(s.chainName).Inc()
+			valuation.PriceError = err.Error()
+			return valuation
+		}
+
+		valuation.AmountUSD = pricing.Value(raw, *decimals, price)
+		valuation.PriceUSD = pricing.FormatPrice(price)
+		return valuation
+	}
+
+	ether := uint8(18)
+	for i, event := range events {
+		switch e := event.(type) {
+		case TxEvent:
+			e.Valuation = value(pricing.NativeAsset, e.AmountWei, &ether)
+			events[i] = e
+		case InternalTransferEvent:
+			e.Valuation = value(pricing.NativeAsset, e.AmountWei, &ether)
+			events[i] = e
+		case WithdrawalEvent:
+			e.Valuation = value(pricing.NativeAsset, e.AmountWei, &ether)
+			events[i] = e
+		case BlockRewardEvent:
+			e.Valuation = value(pricing.NativeAsset, e.RewardWei, &ether)
+			events[i] = e
+		case TokenEvent:
+			e.Valuation = value(e.Token, e.Amount, e.Decimals)
+			events[i] = e
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_ValuesEventsInUSD(t *testing.T) {
+	priceFile := filepath.Join(t.TempDir(), "prices.json")
+	prices := `{"native": "2000", "` + testToken.Hex() + `": "0.999"}`
+	require.NoError(t, os.WriteFile(priceFile, []byte(prices), 0644))
+
+	fake := fakechain.New(1)
+	fake.SetCall(testToken, decimalsSelector, common.BigToHash(big.NewInt(6)).Bytes())
+	unpriced := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	fake.SetCall(unpriced, decimalsSelector, common.BigToHash(big.NewInt(18)).Bytes())
+
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.PriceSource = PriceSourceFile
+	cfg.PriceFile = priceFile
+	env := newTestEnv(t, fake, cfg)
+	require.NoError(t, env.scanner.Start())
+
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	call := env.signTx(t, &types.DynamicFeeTx{Gas: 60000, To: &testToken})
+	env.chain.AddLogs(call.Hash(),
+		erc20Transfer(testToken, other, env.user, 2_000_000),
+		erc20Transfer(unpriced, other, env.user, 5),
+	)
+	env.chain.AppendBlock(env.transferToUser(t, 1_500_000_000_000_000_000), call)
+
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	assert.Equal(t, "3000.00", events[0].AmountUSD)
+	assert.Equal(t, "2000", events[0].PriceUSD)
+	assert.Equal(t, PriceSourceFile, events[0].PriceSource)
+	assert.Empty(t, events[0].PriceError)
+
+	tokens := waitFor[TokenEvent](t, env.recorder, 2)
+	assert.Equal(t, "2.00", tokens[0].AmountUSD)
+	assert.Equal(t, "0.999", tokens[0].PriceUSD)
+
+	// Transfers without a price are published without a USD value
+	assert.Empty(t, tokens[1].AmountUSD)
+	assert.Empty(t, tokens[1].PriceUSD)
+	assert.Equal(t, "no price available", tokens[1].PriceError)
+}
@@ -1,31 +1,54 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
 	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
 )
 
-const (
-	Confirmations = 12  // Number of confirmations before processing a block to handle reorgs
-	NumWorkers    = 4   // Number of worker for transaction processing
-	JobQueueSize  = 100 // Bounded channel size for jobs
-)
-
 func (s *Scanner) Start() error {
-	sub, err := s.client.SubscribeNewHead(s.ctx, s.headersChan)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe: %v", err)
+	if err := s.subscribe(); err != nil {
+		return err
 	}
-	s.subscription = sub
 
 	go s.processHeaders()
 
 	return nil
 }
 
+// subscribe subscribes to new heads on the pool's active provider
+func (s *Scanner) subscribe() error {
+	// The subscription follows the active provider, so earlier provider
+	// changes are handled by it and must not trigger another resubscribe
+	select {
+	case <-s.pool.Changes():
+	default:
+	}
+
+	active := s.pool.Active()
+	s.client = active.Client
+	s.provider = active.Name
+	s.polling = usePolling(s.headSource, active.URL)
+	s.tracing.Store(s.traceInternal)
+	s.headersChan = make(chan *types.Header)
+
+	if s.polling {
+		s.logger.Infof("Polling for new heads every %s", s.pollInterval)
+		s.subscription = s.pollNewHeads(s.headersChan)
+	} else {
+		sub, err := s.client.SubscribeNewHead(s.ctx, s.headersChan)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe: %v", err)
+		}
+		s.subscription = sub
+	}
+	return nil
+}
+
 func (s *Scanner) Stop() {
 	if s.subscription != nil {
 		s.subscription.Unsubscribe()
@@ -38,10 +61,56 @@ func (s *Scanner) Stop() {
 	s.logger.Infof("Stopped Ethereum block scanner")
 }
 
+// catchUp processes every block from the checkpoint up to the current final block.
+// The head keeps moving while we catch up, so it is re-read until we are in sync.
+func (s *Scanner) catchUp() error {
+	if s.lastBlock == 0 {
+		s.logger.Infof("No checkpoint found, skipping catch-up")
+		return nil
+	}
+
+	for {
+		target, err := s.targetBlock()
+		if err != nil {
+			return err
+		}
+		if target <= s.lastBlock {
+			metrics.CatchUpRemainingBlocks.WithLabelValues(s.chainName).Set(0)
+			return nil
+		}
+
+		s.logger.Infow("Catching up from checkpoint",
+			"from", s.lastBlock+1,
+			"to", target,
+		)
+
+		err = s.processRange(s.lastBlock+1, target, func(blockNumber uint64) {
+			s.commitBlock(blockNumber)
+
+			metrics.CatchUpBlocksProcessed.WithLabelValues(s.chainName).Inc()
+			metrics.CatchUpRemainingBlocks.WithLabelValues(s.chainName).Set(float64(target - blockNumber))
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// commitBlock marks a block as processed and persists the checkpoint
+func (s *Scanner) commitBlock(blockNumber uint64) {
+	s.lastBlock = blockNumber
+	s.prunePublished(blockNumber)
+	if err := storage.WriteLastProcessedBlock(s.checkpointFile, blockNumber); err != nil {
+		s.logger.Warnf("Failed to save checkpoint: %v", err)
+	}
+}
+
 // processHeaders processes incoming Ethereum headers with safe confirmations
 func (s *Scanner) processHeaders() {
-	// Queue to hold incoming blocks until they have enough confirmations
-	blockQueue := make([]*types.Header, 0)
+	if err := s.catchUp(); err != nil {
+		s.logger.Errorf("Catch-up stopped at block %d: %v", s.lastBlock, err)
+		s.reportError(err)
+	}
 
 	for {
 		select {
@@ -49,23 +118,67 @@ func (s *Scanner) processHeaders() {
 			return
 		case err := <-s.subscription.Err():
 			s.logger.Infof("Subscription error: %v. Reconnecting...", err)
-			time.Sleep(5 * time.Second)
+			s.pool.MarkFailed(s.provider, err)
+			time.Sleep(s.retryDelay)
+			s.tryReconnect()
+			return
+		case <-s.pool.Changes():
+			s.logger.Infof("Active provider changed. Resubscribing...")
 			s.tryReconnect()
+			return
 		case header := <-s.headersChan:
-			blockQueue = append(blockQueue, header)
-			s.logger.Infof("Adding block to the queue")
-
-			// Process blocks that have enough confirmations
-			for len(blockQueue) > Confirmations {
-				toProcess := blockQueue[0]
-				blockQueue = blockQueue[1:]
-
-				blockNumber := toProcess.Number.Uint64()
-				if blockNumber > s.lastBlock {
-					s.processNewBlock(blockNumber)
-					s.lastBlock = blockNumber
-				}
+			s.handleHeader(header)
+		}
+	}
+}
+
+// handleHeader tracks a new head and processes blocks that became final
+func (s *Scanner) handleHeader(header *types.Header) {
+	if s.reorgPending || s.chain.isReorg(header) {
+		if err := s.handleReorg(header); err != nil {
+			s.reportError(err)
+			if !errors.Is(err, ErrReorgTooDeep) {
+				// Keep the tracked chain and checkpoint as they are, the next
+				// header no longer conflicts with them so the flag retries the reorg
+				s.logger.Errorf("Failed to handle reorg, retrying on the next header: %v", err)
+				s.reorgPending = true
+				return
 			}
+
+			// The common ancestor is out of reach, so events of orphaned blocks
+			// cannot be retracted. Track the new chain from this header on.
+			s.logger.Errorf("Failed to handle reorg, tracking the new chain from block %d: %v", header.Number.Uint64(), err)
+			s.chain.rewind(0)
 		}
+		s.reorgPending = false
+	}
+	s.chain.add(header.Number.Uint64(), header.Hash())
+
+	// Process blocks that are final under the finality policy
+	target, err := s.targetForHeader(header)
+	if err != nil {
+		s.logger.Errorf("Failed to get final block: %v", err)
+		s.reportError(err)
+		return
+	}
+	if target > 0 {
+		s.processUpTo(target)
+	}
+	if s.lifecycle {
+		s.advanceLifecycle(header.Number.Uint64())
+	}
+}
+
+// processUpTo processes all blocks after the checkpoint up to and including
+// blockNumber, so blocks missed while disconnected are not skipped
+func (s *Scanner) processUpTo(blockNumber uint64) {
+	from := s.lastBlock + 1
+	if s.lastBlock == 0 {
+		from = blockNumber
+	}
+
+	if err := s.processRange(from, blockNumber, s.commitBlock); err != nil {
+		s.logger.Errorf("Failed to process blocks up to %d: %v", blockNumber, err)
+		s.reportError(err)
 	}
 }
@@ -0,0 +1,421 @@
+package scanner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/bloom"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/chain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/config"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/logger"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is an in-memory publisher collecting every published event
+type recorder struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+func (r *recorder) PublishEvent(event interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// eventsOf returns the recorded events of type T in publication order
+func eventsOf[T any](r *recorder) []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []T
+	for _, e := range r.events {
+		if event, ok := e.(T); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// waitFor waits until at least count events of type T are recorded and returns them
+func waitFor[T any](t *testing.T, r *recorder, count int) []T {
+	require.Eventually(t, func() bool {
+		return len(eventsOf[T](r)) >= count
+	}, 5*time.Second, 10*time.Millisecond)
+	return eventsOf[T](r)
+}
+
+// testEnv is a scanner running against an in-memory chain
+type testEnv struct {
+	chain    *fakechain.Chain
+	scanner  *Scanner
+	recorder *recorder
+	key      *ecdsa.PrivateKey
+	nonce    uint64
+	user     common.Address
+}
+
+func newTestConfig(t *testing.T) *config.Config {
+	return &config.Config{
+		EthereumNodeURLs:       []string{"fake://primary"},
+		CheckpointFile:         filepath.Join(t.TempDir(), "checkpoint.txt"),
+		FetchConcurrency:       2,
+		HeadSource:             HeadSourceSubscribe,
+		PollInterval:           10 * time.Millisecond,
+		ProviderMaxLag:         5,
+		ProviderHealthInterval: time.Hour,
+		FinalityPolicy:         FinalityDepth,
+		Confirmations:          2,
+	}
+}
+
+func newTestEnv(t *testing.T, fake *fakechain.Chain, cfg *config.Config) *testEnv {
+	return newTestEnvWithChains(t, cfg, map[string]*fakechain.Chain{cfg.EthereumNodeURLs[0]: fake})
+}
+
+// newTestEnvWithChains dials every node URL of cfg to its chain in chains, so
+// tests can run several providers. The first node URL is the test chain.
+func newTestEnvWithChains(t *testing.T, cfg *config.Config, chains map[string]*fakechain.Chain) *testEnv {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	user := common.HexToAddress("0x742d35cc6634c0532925a3b844bc454e4438f44e")
+	addressMap := map[string]string{strings.ToLower(user.Hex()): "user1"}
+
+	bloomFilter := bloom.New(1000, 7)
+	for addr := range addressMap {
+		bloomFilter.Add(addr)
+	}
+
+	rec := &recorder{}
+	dial := func(ctx context.Context, rawURL string) (chain.ChainClient, error) {
+		fake, ok := chains[rawURL]
+		if !ok {
+			return nil, fmt.Errorf("unknown node %s", rawURL)
+		}
+		return fake, nil
+	}
+	s, err := NewWithDialer(ctx, cfg, logger.NewNoOpLogger(), bloomFilter, addressMap, rec, dial)
+	require.NoError(t, err)
+	s.retryDelay = 10 * time.Millisecond
+
+	return &testEnv{
+		chain:    chains[cfg.EthereumNodeURLs[0]],
+		scanner:  s,
+		recorder: rec,
+		key:      key,
+		user:     user,
+	}
+}
+
+// signTx signs txdata with the test key at the next nonce of the key. Dynamic
+// fee, blob and set-code transactions pay a 1 gwei tip with a 10 gwei fee cap.
+func (e *testEnv) signTx(t *testing.T, txdata types.TxData) *types.Transaction {
+	chainID := uint256.NewInt(1)
+	tip, feeCap := uint256.NewInt(1_000_000_000), uint256.NewInt(10_000_000_000)
+	switch tx := txdata.(type) {
+	case *types.DynamicFeeTx:
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap = chainID.ToBig(), e.nonce, tip.ToBig(), feeCap.ToBig()
+	case *types.BlobTx:
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap = chainID, e.nonce, tip, feeCap
+	case *types.SetCodeTx:
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap = chainID, e.nonce, tip, feeCap
+	}
+	e.nonce++
+
+	signed, err := types.SignNewTx(e.key, types.LatestSignerForChainID(chainID.ToBig()), txdata)
+	require.NoError(t, err)
+	return signed
+}
+
+// transferToUser returns a signed transfer of amount wei to the monitored user
+func (e *testEnv) transferToUser(t *testing.T, amount int64) *types.Transaction {
+	return e.signTx(t, &types.DynamicFeeTx{Gas: 21000, To: &e.user, Value: big.NewInt(amount)})
+}
+
+func TestScanner_ProcessesConfirmedBlocks(t *testing.T) {
+	env := newTestEnv(t, fakechain.New(1), newTestConfig(t))
+	require.NoError(t, env.scanner.Start())
+
+	tx := env.transferToUser(t, 1e18)
+	block := env.chain.AppendBlock(tx)
+	env.chain.AppendBlock()
+
+	// One confirmation short, nothing may be published yet
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, eventsOf[TxEvent](env.recorder))
+
+	env.chain.AppendBlock()
+	events := waitFor[TxEvent](t, env.recorder, 1)
+
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Equal(t, tx.Hash().Hex(), events[0].Hash)
+	assert.Equal(t, block.Hash().Hex(), events[0].BlockHash)
+	assert.Equal(t, "1.00000000", events[0].AmountEth)
+	assert.Equal(t, EventStateConfirmed, events[0].State)
+}
+
+func TestScanner_CatchesUpFromCheckpoint(t *testing.T) {
+	fake := fakechain.New(1)
+	cfg := newTestConfig(t)
+	env := newTestEnv(t, fake, cfg)
+
+	// Blocks mined while the scanner was down
+	fake.AppendBlock()
+	fake.AppendBlock(env.transferToUser(t, 1))
+	fake.AppendBlock(env.transferToUser(t, 2))
+	fake.AppendBlock()
+	fake.AppendBlock()
+
+	require.NoError(t, storage.WriteLastProcessedBlock(cfg.CheckpointFile, 1))
+	env.scanner.lastBlock = 1
+	require.NoError(t, env.scanner.Start())
+
+	events := waitFor[TxEvent](t, env.recorder, 2)
+	assert.Equal(t, uint64(2), events[0].BlockNumber)
+	assert.Equal(t, uint64(3), events[1].BlockNumber)
+
+	require.Eventually(t, func() bool {
+		checkpoint, err := storage.ReadLastProcessedBlock(cfg.CheckpointFile)
+		return err == nil && checkpoint == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestScanner_RetractsReorgedTransactions(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	tx := env.transferToUser(t, 1)
+	orphaned := env.chain.AppendBlock(tx)
+	waitFor[TxEvent](t, env.recorder, 1)
+
+	// Replace block 1 with a longer branch that does not include the transaction
+	env.chain.Fork(0)
+	env.chain.AppendBlock()
+	env.chain.AppendBlock()
+
+	events := waitFor[TxEvent](t, env.recorder, 2)
+	assert.Equal(t, EventStateConfirmed, events[0].State)
+	assert.Equal(t, EventStateRetracted, events[1].State)
+	assert.Equal(t, tx.Hash().Hex(), events[1].Hash)
+	assert.Equal(t, orphaned.Hash().Hex(), events[1].BlockHash)
+
+	// The transaction is mined again on the new branch
+	env.chain.AppendBlock(tx)
+	events = waitFor[TxEvent](t, env.recorder, 3)
+	assert.Equal(t, EventStateConfirmed, events[2].State)
+	assert.Equal(t, uint64(3), events[2].BlockNumber)
+}
+
+func TestScanner_RetriesReorgAfterHeaderFailure(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	s := env.scanner
+
+	// Headers are handed to the scanner directly, so the fork is first seen at block 3
+	tx := env.transferToUser(t, 1)
+	s.handleHeader(env.chain.AppendBlock().Header())
+	orphaned := env.chain.AppendBlock(tx)
+	s.handleHeader(orphaned.Header())
+	require.Len(t, eventsOf[TxEvent](env.recorder), 1)
+
+	env.chain.Fork(1)
+	env.chain.AppendBlock()
+	branch := env.chain.AppendBlock(tx)
+	env.chain.FailHeaderByHash(errors.New("header not found"))
+	s.handleHeader(branch.Header())
+
+	assert.Len(t, eventsOf[TxEvent](env.recorder), 1, "nothing should be retracted or processed while the reorg is unresolved")
+	assert.Equal(t, uint64(2), s.lastBlock)
+	_, tracked := s.chain.hash(3)
+	assert.False(t, tracked, "the failed header should not be tracked")
+
+	// The next header does not conflict with the tracked chain, the pending reorg is retried
+	env.chain.FailHeaderByHash(nil)
+	s.handleHeader(env.chain.AppendBlock().Header())
+
+	events := eventsOf[TxEvent](env.recorder)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventStateRetracted, events[1].State)
+	assert.Equal(t, orphaned.Hash().Hex(), events[1].BlockHash)
+	assert.Equal(t, EventStateConfirmed, events[2].State)
+	assert.Equal(t, branch.Hash().Hex(), events[2].BlockHash)
+	assert.Equal(t, uint64(4), s.lastBlock)
+}
+
+func TestScanner_ResubscribesAfterDroppedSubscription(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	env.chain.AppendBlock(env.transferToUser(t, 1))
+	waitFor[TxEvent](t, env.recorder, 1)
+
+	env.chain.DropSubscriptions(errors.New("connection reset"))
+	require.Eventually(t, func() bool {
+		return env.chain.Subscribers() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	env.chain.AppendBlock(env.transferToUser(t, 2))
+	events := waitFor[TxEvent](t, env.recorder, 2)
+	assert.Equal(t, uint64(2), events[1].BlockNumber)
+}
+
+func TestScanner_FailsOverToNextProvider(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	cfg.EthereumNodeURLs = []string{"fake://primary", "fake://secondary"}
+	secondary := fakechain.New(1)
+	env := newTestEnvWithChains(t, cfg, map[string]*fakechain.Chain{
+		"fake://primary":   fakechain.New(1),
+		"fake://secondary": secondary,
+	})
+	require.NoError(t, env.scanner.Start())
+	require.Equal(t, 1, env.chain.Subscribers())
+
+	env.chain.DropSubscriptions(errors.New("connection reset"))
+	require.Eventually(t, func() bool {
+		return secondary.Subscribers() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, env.chain.Subscribers())
+
+	secondary.AppendBlock(env.transferToUser(t, 1))
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	assert.Equal(t, uint64(1), events[0].BlockNumber)
+}
+
+func TestScanner_PollsHeadsWithoutSubscription(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.HeadSource = HeadSourcePoll
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	env.chain.AppendBlock(env.transferToUser(t, 1))
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	assert.Equal(t, uint64(1), events[0].BlockNumber)
+	assert.Zero(t, env.chain.Subscribers())
+}
+
+func TestScanner_EnrichesEventsWithReceipts(t *testing.T) {
+	tests := []struct {
+		name          string
+		skipFailedTxs bool
+		wantStatus    []string
+	}{
+		{"failed transactions are flagged", false, []string{TxStatusSuccess, TxStatusFailed}},
+		{"failed transactions are filtered", true, []string{TxStatusSuccess}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.Confirmations = 0
+			cfg.SkipFailedTxs = tt.skipFailedTxs
+			env := newTestEnv(t, fakechain.New(1), cfg)
+			require.NoError(t, env.scanner.Start())
+
+			succeeded := env.transferToUser(t, 1)
+			reverted := env.transferToUser(t, 2)
+			env.chain.FailTransaction(reverted.Hash())
+			env.chain.AppendBlock(succeeded, reverted)
+
+			// A second block proves the first one was fully processed
+			env.chain.AppendBlock(env.transferToUser(t, 3))
+			events := waitFor[TxEvent](t, env.recorder, len(tt.wantStatus)+1)
+
+			var statuses []string
+			for _, event := range events[:len(events)-1] {
+				statuses = append(statuses, event.Status)
+			}
+			assert.Equal(t, tt.wantStatus, statuses)
+
+			// 21000 gas at base fee 1 gwei plus 1 gwei tip
+			assert.Equal(t, uint8(types.DynamicFeeTxType), events[0].TxType)
+			assert.Equal(t, uint64(21000), events[0].GasUsed)
+			assert.Equal(t, "2000000000", events[0].EffectiveGasPrice)
+			assert.Equal(t, "42000000000000", events[0].FeeWei)
+			assert.Equal(t, "0.00004200", events[0].FeeEth)
+		})
+	}
+}
+
+func TestScanner_EmitsEventPerMonitoredParty(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+
+	sender := crypto.PubkeyToAddress(env.key.PublicKey)
+	env.scanner.watchAddress(strings.ToLower(sender.Hex()), "user2")
+	require.NoError(t, env.scanner.Start())
+
+	tx := env.transferToUser(t, 1)
+	env.chain.AppendBlock(tx)
+
+	events := waitFor[TxEvent](t, env.recorder, 2)
+	assert.Equal(t, "user2", events[0].UserID)
+	assert.Equal(t, DirectionOutgoing, events[0].Direction)
+	assert.Equal(t, "user1", events[0].CounterpartyUserID)
+	assert.Equal(t, "user1", events[1].UserID)
+	assert.Equal(t, DirectionIncoming, events[1].Direction)
+	assert.Equal(t, "user2", events[1].CounterpartyUserID)
+	assert.Equal(t, events[0].Hash, events[1].Hash)
+
+	// A transfer to self is a single event without counterparty
+	self := env.signTx(t, &types.DynamicFeeTx{Gas: 21000, To: &sender, Value: big.NewInt(1)})
+	env.chain.AppendBlock(self)
+	env.chain.AppendBlock(env.transferToUser(t, 2))
+
+	events = waitFor[TxEvent](t, env.recorder, 5)
+	assert.Equal(t, "user2", events[2].UserID)
+	assert.Equal(t, DirectionSelf, events[2].Direction)
+	assert.Empty(t, events[2].CounterpartyUserID)
+	assert.Equal(t, uint64(3), events[3].BlockNumber)
+}
+
+func TestScanner_TagsEventsWithChainID(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Chain = "mainnet"
+	cfg.ChainID = 1
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	env.chain.AppendBlock(env.transferToUser(t, 1))
+
+	events := waitFor[TxEvent](t, env.recorder, 1)
+	assert.Equal(t, uint64(1), events[0].ChainID)
+}
+
+func TestScanner_RejectsNodeOfAnotherChain(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Chain = "base"
+	cfg.ChainID = 8453
+	dial := func(ctx context.Context, rawURL string) (chain.ChainClient, error) {
+		return fakechain.New(1), nil
+	}
+
+	_, err := NewWithDialer(context.Background(), cfg, logger.NewNoOpLogger(), bloom.New(1000, 7), map[string]string{}, &recorder{}, dial)
+	assert.ErrorContains(t, err, "reports chain id 1, expected 8453")
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/metrics"
+	"go.uber.org/multierr"
+)
+
+// WithdrawalEvent represents a beacon chain withdrawal credited to a monitored address
+type WithdrawalEvent struct {
+	BaseEvent
+	Direction       string `json:"direction"`
+	Address         string `json:"address"`
+	ValidatorIndex  uint64 `json:"validatorIndex"`
+	WithdrawalIndex uint64 `json:"withdrawalIndex"`
+	AmountGwei      string `json:"amountGwei"`
+	AmountWei       string `json:"amountWei"`
+	AmountEth       string `json:"amountEth"`
+	Valuation
+}
+
+// validate checks that all the required fields of a withdrawal event are present
+func (e WithdrawalEvent) validate() error {
+	var validatorErr error
+	if e.UserID == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("userId is required"))
+	}
+	if e.Address == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("address is required"))
+	}
+	if e.AmountWei == "" {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("amountWei is required"))
+	}
+	if e.BlockNumber == 0 {
+		validatorErr = multierr.Append(validatorErr, fmt.Errorf("blockNumber is required"))
+	}
+	return validatorErr
+}
+
+func (e WithdrawalEvent) withState(state string) Event { e.State = state; return e }
+
+// matchWithdrawals returns an event for every withdrawal of a block paid to a
+// monitored address. Withdrawals carry no transaction, so they are matched on
+// the recipient alone.
+func (s *Scanner) matchWithdrawals(block *types.Block) []Event {
+	var events []Event
+	for _, withdrawal := range block.Withdrawals() {
+		address := strings.ToLower(withdrawal.Address.Hex())
+		userID, ok := s.monitored(address)
+		if !ok {
+			continue
+		}
+
+		gwei := new(big.Int).SetUint64(withdrawal.Amount)
+		wei := new(big.Int).Mul(gwei, big.NewInt(params.GWei))
+		events = append(events, WithdrawalEvent{
+			BaseEvent:       s.newBaseEvent(EventTypeWithdrawal, userID, block),
+			Direction:       DirectionIncoming,
+			Address:         address,
+			ValidatorIndex:  withdrawal.Validator,
+			WithdrawalIndex: withdrawal.Index,
+			AmountGwei:      gwei.String(),
+			AmountWei:       wei.String(),
+			AmountEth:       WeiToEther(wei),
+		})
+		metrics.WithdrawalsDetected.WithLabelValues(s.chainName).Inc()
+	}
+	return events
+}
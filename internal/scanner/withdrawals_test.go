@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/fakechain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_DetectsWithdrawals(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Confirmations = 0
+	env := newTestEnv(t, fakechain.New(1), cfg)
+	require.NoError(t, env.scanner.Start())
+
+	other := common.HexToAddress("0x7777777777777777777777777777777777777777")
+	block := env.chain.AppendBlockWithBody(types.Body{
+		Withdrawals: types.Withdrawals{
+			{Index: 100, Validator: 5000, Address: other, Amount: 1},
+			{Index: 101, Validator: 5001, Address: env.user, Amount: 32_000_000_000},
+		},
+	})
+
+	events := waitFor[WithdrawalEvent](t, env.recorder, 1)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, EventTypeWithdrawal, events[0].Type)
+	assert.Equal(t, "user1", events[0].UserID)
+	assert.Equal(t, DirectionIncoming, events[0].Direction)
+	assert.Equal(t, strings.ToLower(env.user.Hex()), events[0].Address)
+	assert.Equal(t, uint64(5001), events[0].ValidatorIndex)
+	assert.Equal(t, uint64(101), events[0].WithdrawalIndex)
+	assert.Equal(t, "32000000000", events[0].AmountGwei)
+	assert.Equal(t, "32000000000000000000", events[0].AmountWei)
+	assert.Equal(t, "32.00000000", events[0].AmountEth)
+	assert.Equal(t, block.Hash().Hex(), events[0].BlockHash)
+	assert.Equal(t, EventStateConfirmed, events[0].State)
+}
@@ -42,3 +42,36 @@ func ReadAddresses(filename string) (map[string]string, error) {
 
 	return addresses, nil
 }
+
+// AppendAddress appends a user ID and Ethereum address to a CSV address file
+func AppendAddress(filename, userId, address string) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Terminate a last line written without a trailing newline
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			if _, err := file.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{userId, address}); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
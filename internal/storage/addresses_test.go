@@ -61,3 +61,23 @@ user2,0x1111222233334444
 		})
 	}
 }
+
+func TestScanner_AppendAddress(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "addresses.csv")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	// The last row deliberately lacks a trailing newline
+	_, err = tmpFile.WriteString("userId,address\nuser1,0xAAAABBBBCCCCDDDD")
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	assert.NoError(t, storage.AppendAddress(tmpFile.Name(), "user1", "0x1111222233334444"))
+
+	addressMap, err := storage.ReadAddresses(tmpFile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"0xaaaabbbbccccdddd": "user1",
+		"0x1111222233334444": "user1",
+	}, addressMap)
+}
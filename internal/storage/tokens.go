@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// TokenMetadata is the metadata of a token contract. Fields the contract does
+// not implement are left empty.
+type TokenMetadata struct {
+	Decimals *uint8 `json:"decimals,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+// ReadTokenMetadata reads a JSON file of token metadata keyed by contract address
+func ReadTokenMetadata(filename string) (map[string]TokenMetadata, error) {
+	tokens := make(map[string]TokenMetadata)
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return tokens, nil
+	}
+
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// WriteTokenMetadata replaces a token metadata file. The file is written to a
+// temporary file first so a crash never leaves a truncated cache behind.
+func WriteTokenMetadata(filename string, tokens map[string]TokenMetadata) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
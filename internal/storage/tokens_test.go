@@ -0,0 +1,30 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nagdahimanshu/ethereum-block-scanner/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_TokenMetadata(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "token-metadata.json")
+
+	// A missing cache file is an empty cache
+	tokens, err := storage.ReadTokenMetadata(filename)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	six := uint8(6)
+	tokens = map[string]storage.TokenMetadata{
+		"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48": {Decimals: &six, Symbol: "USDC", Name: "USD Coin"},
+		"0x0000000000000000000000000000000000000001": {},
+	}
+	require.NoError(t, storage.WriteTokenMetadata(filename, tokens))
+
+	read, err := storage.ReadTokenMetadata(filename)
+	require.NoError(t, err)
+	assert.Equal(t, tokens, read)
+}